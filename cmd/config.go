@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/parser"
+	"github.com/elseano/dollop/pkg/dollop"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// sampleWidth is how much of each sample line is shown by config check.
+const sampleWidth = 80
+
+var (
+	initOut   string
+	initForce bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "check and create config files.",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check [sample.log]",
+	Short: "validate the config, and show how it applies to sample logs.",
+	Long: `Check reports every problem with the config at once, such as blank
+fields and templates which don't parse, along with where each one is.
+
+Given a sample log file, or - for stdin, each line is then processed as a
+dry run, showing which group it would be added to, the tags it would be
+given and the status it would display.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ok, err := runConfigCheck(os.Stdout, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init [sample.log...]",
+	Short: "write a starter config, inferred from sample logs.",
+	Long: `Init reads sample logs from the given files, or from stdin, and guesses
+the message, level and timestamp fields from how often each field appears
+and how many values it has. Fields which look like IDs shared by several
+lines, such as request_id or trace_id, become groups, and error, status
+and duration fields become tags.
+
+The config is written with comments explaining each guess, ready to be
+checked and adjusted.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigInit(args); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func runConfigInit(args []string) error {
+	if len(args) == 0 && isatty.IsTerminal(os.Stdin.Fd()) {
+		return errors.New("give sample log files, or pipe a sample to stdin")
+	}
+
+	reader, err := input.Open(args, false)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	records := []map[string]interface{}{}
+
+	for {
+		line, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if record, err := parser.Auto(line.Text); err == nil {
+			records = append(records, record)
+		}
+	}
+
+	if len(records) == 0 {
+		return errors.New("no JSON, logfmt or syslog lines were found in the sample")
+	}
+
+	suggestion := config.Infer(records)
+
+	if initOut == "-" {
+		return suggestion.WriteYAML(os.Stdout)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if initForce {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(initOut, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use --force to replace it", initOut)
+	} else if err != nil {
+		return err
+	}
+
+	if err := suggestion.WriteYAML(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s from %d sample lines\n", initOut, len(records))
+
+	return file.Close()
+}
+
+// runConfigCheck writes the config's problems, then the dry run over the sample. Returns false if
+// the config has problems.
+func runConfigCheck(w io.Writer, args []string) (bool, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		fmt.Fprintf(w, "Config: %s\n", path)
+	} else {
+		fmt.Fprintln(w, "No config file found, checking the defaults")
+	}
+
+	// A config file which doesn't parse is reported like any other problem.
+	var problems []error
+
+	cfg, err := config.Read()
+	if err != nil {
+		problems = append(problems, err)
+	} else {
+		for _, p := range cfg.Check() {
+			problems = append(problems, p)
+		}
+	}
+
+	switch len(problems) {
+	case 0:
+		fmt.Fprintln(w, "No problems found")
+	case 1:
+		fmt.Fprintln(w, "1 problem found:")
+	default:
+		fmt.Fprintf(w, "%d problems found:\n", len(problems))
+	}
+
+	for _, p := range problems {
+		fmt.Fprintf(w, "  %s\n", p.Error())
+	}
+
+	if len(problems) > 0 || len(args) == 0 {
+		return len(problems) == 0, nil
+	}
+
+	reader, err := input.Open(args, false)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	return true, dryRun(w, cfg, reader)
+}
+
+// dryRun processes each line of the sample, describing what happened to it.
+func dryRun(w io.Writer, cfg config.Config, reader input.Reader) error {
+	processor, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return err
+	}
+
+	for n := 1; ; n++ {
+		line, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		text := strings.TrimRight(line.Text, "\r\n")
+		if runes := []rune(text); len(runes) > sampleWidth {
+			text = string(runes[:sampleWidth]) + "..."
+		}
+
+		fmt.Fprintf(w, "\nLine %d: %s\n", n, text)
+
+		res := processor.ProcessAt(line.Source, line.Text, line.ReadTime())
+		if res.Continuation {
+			fmt.Fprintln(w, "  continues the previous entry")
+			continue
+		}
+
+		group := res.Group
+		added := group.Lines[len(group.Lines)-1]
+
+		if group.Spec != nil {
+			fmt.Fprintf(w, "  group:  %s %q, value %q\n", group.Description, group.Title, group.Value)
+		} else {
+			fmt.Fprintf(w, "  group:  none, added to %s (%s)\n", group.Title, group.Description)
+		}
+
+		if len(added.Tags) > 0 {
+			tags := make([]string, len(added.Tags))
+			for i, t := range added.Tags {
+				if t.Value == "" {
+					tags[i] = t.Name
+				} else {
+					tags[i] = fmt.Sprintf("%s=%s", t.Name, t.Value)
+				}
+			}
+
+			fmt.Fprintf(w, "  tags:   %s\n", strings.Join(tags, ", "))
+		}
+
+		if res.Status != "" {
+			fmt.Fprintf(w, "  status: %s\n", res.Status)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configCheckCmd)
+	configCmd.AddCommand(configInitCmd)
+
+	configInitCmd.Flags().
+		StringVarP(&initOut, "out", "o", ".dollop.yml", "file to write the config to, or - for stdout")
+	configInitCmd.Flags().
+		BoolVar(&initForce, "force", false, "replace the file if it already exists")
+}
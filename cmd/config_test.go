@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/spf13/viper"
+)
+
+func useConfigFile(t *testing.T, text string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".dollop.yml")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	t.Cleanup(func() {
+		viper.Reset()
+		config.ReadFile()
+	})
+
+	viper.SetConfigFile(path)
+	config.ReadFile()
+}
+
+func TestConfigCheckReportsConfigWhichDoesNotParse(t *testing.T) {
+	useConfigFile(t, "groups:\n  - valueField: [oops\n")
+
+	out := &bytes.Buffer{}
+
+	ok, err := runConfigCheck(out, nil)
+	if err != nil {
+		t.Fatalf("runConfigCheck: %s", err)
+	}
+
+	if ok {
+		t.Error("a config which doesn't parse passed the check")
+	}
+
+	if !strings.Contains(out.String(), "1 problem found:\n  reading .dollop.yml: ") {
+		t.Errorf("output doesn't report the parse error:\n%s", out)
+	}
+}
+
+func TestConfigCheckPassesValidConfig(t *testing.T) {
+	useConfigFile(t, "groups:\n  - valueField: request_id\n    titleField: msg\n    name: Request\n")
+
+	out := &bytes.Buffer{}
+
+	ok, err := runConfigCheck(out, nil)
+	if err != nil || !ok {
+		t.Errorf("valid config failed the check: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+var (
+	exportPath    string
+	exportFormat  string
+	exportColumns []string
+	exportQuery   string
+)
+
+// runExport reads all of the input, then writes it out without starting the UI.
+func runExport(cfg config.Config, reader input.Reader) error {
+	if follow {
+		return errors.New("--export cannot be used with --follow")
+	}
+
+	opts := dollop.ExportOptions{Format: exportFormat, Columns: exportColumns}
+	if opts.Format == "" {
+		opts.Format = dollop.ExportFormatFor(exportPath)
+	}
+
+	if exportQuery != "" {
+		query, err := dollop.ParseQuery(exportQuery)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+
+		opts.Query = query
+	}
+
+	processor, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := processAll(processor, reader); err != nil {
+		return err
+	}
+
+	if exportPath == "-" {
+		return dollop.Export(os.Stdout, processor.Groups(), opts)
+	}
+
+	file, err := os.Create(exportPath)
+	if err != nil {
+		return err
+	}
+
+	if err := dollop.Export(file, processor.Groups(), opts); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// processAll reads lines until the input runs out, or the command it's reading from exits.
+func processAll(processor *dollop.Processor, reader input.Reader) error {
+	var exitErr *input.ExitError
+
+	for {
+		line, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) || errors.As(err, &exitErr) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		processor.ProcessAt(line.Source, line.Text, line.ReadTime())
+	}
+
+	processor.Reorder()
+
+	return nil
+}
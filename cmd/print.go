@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/tui"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flushAfter time.Duration
+	colour     string
+)
+
+var printCmd = &cobra.Command{
+	Use:   "print [file...] | print -- command [args...]",
+	Short: "write formatted logs to stdout instead of the full screen view.",
+	Long: `Print writes one coloured line per log entry to stdout, for CI logs,
+"less -R" and terminal scrollback. This is used automatically when stdout
+isn't a terminal.
+
+Each entry is prefixed with its group. With --flush-after, each group's
+entries are written together instead, once the group has gone quiet.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := config.Get()
+
+		reader, err := openInput(cmd, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer reader.Close()
+
+		if err := runPrint(config, reader); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func runPrint(cfg config.Config, reader input.Reader) error {
+	switch colour {
+	case "always":
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case "never":
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case "auto":
+	default:
+		return fmt.Errorf("--color must be auto, always or never, not %q", colour)
+	}
+
+	opts := tui.PrintOptions{
+		FlushAfter: flushAfter,
+		Colour:     lipgloss.ColorProfile() != termenv.Ascii,
+	}
+
+	return tui.Print(os.Stdout, cfg, reader, opts)
+}
+
+func init() {
+	rootCmd.AddCommand(printCmd)
+
+	printCmd.Flags().
+		DurationVar(&flushAfter, "flush-after", 0, "write each group's entries together, once it's been quiet this long")
+	rootCmd.PersistentFlags().
+		StringVar(&colour, "color", "auto", "colour printed output: auto, always or never")
+}
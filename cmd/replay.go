@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/pkg/dollop"
+	"github.com/spf13/cobra"
+)
+
+// replaySpeed is how many times faster than the original the input is replayed, or zero when it
+// isn't being replayed.
+var replaySpeed float64
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [file...] [--speed 4x]",
+	Short: "replay logs at the pace they were written.",
+	Long: `Replay feeds logs into Dollop paced by their timestamps, to see how a
+problem unfolded, demo a config, or try status rules with realistic timing.
+Lines without a timestamp aren't delayed. Recorded sessions replay at the
+pace they were read.
+
+While replaying, P pauses the replay, n steps to the next line, [ and ]
+skip back and ahead 10 seconds, and + and - change the speed. p still
+pauses the display, while the replay carries on. Every line is kept in
+memory so the replay can be rewound.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		speed, err := parseSpeed(cmd.Flag("speed").Value.String())
+		if err != nil {
+			cobra.CheckErr(err)
+		}
+
+		if follow {
+			cobra.CheckErr(errors.New("replay cannot be used with --follow"))
+		}
+
+		replaySpeed = speed
+		rootCmd.Run(cmd, args)
+	},
+}
+
+// parseSpeed reads a speed like 4x, 0.5x or 2.
+func parseSpeed(text string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(text), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected something like 4x or 0.5x", text)
+	}
+
+	return speed, nil
+}
+
+// replay paces the reader by the timestamps of its lines, parsed with the config. The timestamps
+// follow changes to the config file, as the lines do.
+func replay(cfg config.Config, reader input.Reader) (input.Reader, error) {
+	timestamps, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := sync.Mutex{}
+
+	err = config.Watch(func(cfg config.Config, err error) {
+		if err != nil {
+			// Whatever shows the logs reports the error, and the previous config stays in use.
+			return
+		}
+
+		if reloaded, err := dollop.NewProcessor(cfg); err == nil {
+			lock.Lock()
+			timestamps = reloaded
+			lock.Unlock()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return input.NewReplay(reader, func(line input.Line) (time.Time, bool) {
+		// Lines from a session were recorded with the time they were read.
+		if !line.Time.IsZero() {
+			return line.Time, true
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		return timestamps.Timestamp(line.Text)
+	}, replaySpeed), nil
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().
+		String("speed", "1x", "how many times faster than the original to replay, like 4x or 0.5x")
+}
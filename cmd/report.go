@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/report"
+	"github.com/elseano/dollop/pkg/dollop"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOut   string
+	reportTitle string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report --out file.html [file...] | report --out file.html -- command [args...]",
+	Short: "write the logs as a self contained HTML page.",
+	Long: `Report reads all of the logs, then writes them as a single HTML file
+with the groups sidebar, level counts, tags and expandable metadata. It
+can be opened in any browser, without needing Dollop installed.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := config.Get()
+
+		reader, err := openInput(cmd, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer reader.Close()
+
+		if err := runReport(config, reader); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func runReport(cfg config.Config, reader input.Reader) error {
+	if reportOut == "" {
+		return errors.New("--out is required")
+	}
+
+	if follow {
+		return errors.New("report cannot be used with --follow")
+	}
+
+	processor, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := processAll(processor, reader); err != nil {
+		return err
+	}
+
+	if reportOut == "-" {
+		return report.Write(os.Stdout, reportTitle, processor.Groups())
+	}
+
+	file, err := os.Create(reportOut)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(file, reportTitle, processor.Groups()); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().
+		StringVarP(&reportOut, "out", "o", "", "file to write the report to, or - for stdout")
+	reportCmd.Flags().
+		StringVar(&reportTitle, "title", "Dollop report", "title shown at the top of the report")
+}
@@ -1,41 +1,211 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/session"
 	"github.com/elseano/dollop/internal/tui"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var cfgFile string
+var (
+	cfgFile string
+	follow  bool
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "redis-viewer",
-	Short: "view redis data in terminal.",
-	Long:  `Redis Viewer is a tool to view redis data in terminal.`,
+	Use:   "dollop [file...] | dollop -- command [args...]",
+	Short: "view JSON logs in terminal.",
+	Long: `Dollop groups and formats JSON log output in the terminal.
+
+Logs are read from the given files in order, or from stdin when no files
+are given. Use "-" to read stdin alongside files. With --follow, files
+continue to be read as they grow, and are reopened when rotated.
+
+Anything after "--" is run as a command, with its stdout and stderr read
+as separate streams. Interrupt and terminate signals are forwarded to it,
+and it can be restarted from within Dollop.
+
+When stdout isn't a terminal, the logs are printed as with "dollop print".
+
+With --web, the same groups are also served to a browser, with live
+updates. Add --no-ui to only serve the web UI.
+
+With --record, the input is saved to a session file as it's read, along
+with what was selected when Dollop closes. Reopen it with "dollop open".
+
+With --export, everything is read and written to a file instead of being
+shown, which is useful for attaching logs to bug reports.`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		config := config.Get()
+		cfg := config.Get()
+
+		reader, err := openInput(cmd, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer reader.Close()
+
+		_, ownsProcess := reader.(*input.Process)
+
+		var recorder *session.Recorder
+
+		switch {
+		case recordPath != "" && sessionPath != "":
+			log.Fatal("--record cannot be used when reading a session")
+
+		case recordPath != "":
+			recorder, err = session.Create(recordPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer recorder.Close()
+
+			reader = session.Record(reader, recorder)
+
+		case sessionPath != "":
+			// The selection and bookmarks in a reopened session are saved back to it.
+			recorder, err = session.Create(sessionPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer recorder.Close()
+		}
+
+		if replaySpeed > 0 {
+			if reader, err = replay(cfg, reader); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if exportPath != "" {
+			if err := runExport(cfg, reader); err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		}
+
+		terminal := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+
+		var listener net.Listener
+		if webAddr != "" {
+			listener, err = listenWeb()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if noUI || !terminal {
+				if err := runWeb(cfg, reader, listener); err != nil {
+					log.Fatal(err)
+				}
 
-		model, err := tui.New(config)
+				return
+			}
+		}
+
+		// Without a terminal to draw on, print the logs instead.
+		if !terminal {
+			if err := runPrint(cfg, reader); err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		}
+
+		model, err := tui.New(cfg, reader)
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		if listener != nil {
+			serveAlongside(model, listener)
+		}
+
+		if opened, ok := reader.(*session.Reader); ok && opened.State() != nil {
+			model.Restore(*opened.State())
+		}
+
+		if err := config.Watch(model.ReloadConfig); err != nil {
+			log.Print(err)
+		}
+
 		p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-		if err := p.Start(); err != nil {
+		final, err := p.StartReturningModel()
+		if err != nil {
 			log.Fatal("start failed: ", err)
+		}
+
+		if err := saveState(final, recorder); err != nil {
+			log.Print("saving session: ", err)
+		}
+
+		if ownsProcess {
+			fmt.Println("Dollop Closed.")
 		} else {
 			fmt.Println("Dollop Closed.\nSource process may still be running and require an additional ctrl+c to exit.")
 		}
 	},
 }
 
+// openInput reads from the command after "--" if there is one, otherwise from the files given.
+func openInput(cmd *cobra.Command, args []string) (input.Reader, error) {
+	if sessionPath != "" {
+		if len(args) > 0 || follow {
+			return nil, errors.New("files, commands and --follow cannot be used when reading a session")
+		}
+
+		return session.Open(sessionPath)
+	}
+
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		return input.Open(args, follow)
+	}
+
+	if dash > 0 || follow {
+		return nil, errors.New("files and --follow cannot be used when running a command")
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("no command given after --")
+	}
+
+	process, err := input.StartProcess(args[0], args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	forwardSignals(process)
+
+	return process, nil
+}
+
+// forwardSignals passes interrupt and terminate signals on to the child process.
+func forwardSignals(process *input.Process) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		for sig := range signals {
+			process.Signal(sig)
+		}
+	}()
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -50,6 +220,29 @@ func init() {
 
 	rootCmd.PersistentFlags().
 		StringVarP(&cfgFile, "config", "c", "", "config file (default is ./.dollop.yaml)")
+
+	rootCmd.PersistentFlags().
+		String("preset", "", "start from the config for a common logger: "+strings.Join(config.Presets(), ", "))
+	cobra.CheckErr(viper.BindPFlag("preset", rootCmd.PersistentFlags().Lookup("preset")))
+
+	rootCmd.PersistentFlags().
+		BoolVarP(&follow, "follow", "f", false, "keep reading files as they grow, reopening them when rotated")
+	rootCmd.PersistentFlags().
+		StringVar(&sessionPath, "session", "", "read the input from a recorded session, instead of files or stdin")
+	rootCmd.Flags().
+		StringVar(&recordPath, "record", "", "record the input, and what's selected on exit, to a session file")
+	rootCmd.Flags().
+		StringVar(&webAddr, "web", "", "also serve a web UI on an address, such as :8080")
+	rootCmd.Flags().
+		BoolVar(&noUI, "no-ui", false, "with --web, only serve the web UI")
+	rootCmd.Flags().
+		StringVar(&exportPath, "export", "", "write the logs to a file, or - for stdout, instead of showing them")
+	rootCmd.Flags().
+		StringVar(&exportFormat, "format", "", "export format: ndjson, json, csv or text (default from the file extension)")
+	rootCmd.Flags().
+		StringSliceVar(&exportColumns, "columns", nil, "fields to export as csv columns (default time,level,msg)")
+	rootCmd.Flags().
+		StringVar(&exportQuery, "query", "", "only export lines matching a search query")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -71,8 +264,8 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	// If a config file is found, read it in. Errors reading it are returned when the config is loaded.
+	if err := config.ReadFile(); err == nil && viper.ConfigFileUsed() != "" {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/internal/session"
+	"github.com/elseano/dollop/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sessionPath string
+	recordPath  string
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open session-file",
+	Short: "reopen a recorded session.",
+	Long: `Open reads a session recorded with --record, restoring its groups along
+with the selection and bookmarks from when it was closed. Changes to the
+selection and bookmarks are saved back to the session.
+
+The web UI, print, report and export can also read sessions, using
+--session.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionPath = args[0]
+		rootCmd.Run(cmd, nil)
+	},
+}
+
+// saveState records the UI's selection and bookmarks in the session, if there is one.
+func saveState(final tea.Model, recorder *session.Recorder) error {
+	if recorder == nil {
+		return nil
+	}
+
+	switch model := final.(type) {
+	case tui.Model:
+		return recorder.SaveState(model.State())
+	case *tui.Model:
+		return recorder.SaveState(model.State())
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/tui"
+	"github.com/elseano/dollop/internal/web"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+// webBatchSize is the most lines processed before the groups are sorted and browsers are updated.
+const webBatchSize = 1000
+
+var (
+	webAddr string
+	noUI    bool
+)
+
+// listenWeb opens the web UI's address before anything starts, so a port which is in use is reported
+// straight away.
+func listenWeb() (net.Listener, error) {
+	listener, err := net.Listen("tcp", webAddr)
+	if err != nil {
+		return nil, fmt.Errorf("web UI: %w", err)
+	}
+
+	return listener, nil
+}
+
+// serveAlongside serves the web UI from the terminal UI's groups.
+func serveAlongside(model *tui.Model, listener net.Listener) {
+	server := web.NewServer(model.Processor(), tui.GroupsLock())
+	model.OnUpdate(server.Update)
+
+	go server.Serve(listener)
+}
+
+// runWeb serves the web UI without the terminal UI, reading the input itself. It keeps serving
+// after the input runs out, until interrupted.
+func runWeb(cfg config.Config, reader input.Reader, listener net.Listener) error {
+	processor, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return err
+	}
+
+	lock := &sync.Mutex{}
+	server := web.NewServer(processor, lock)
+
+	fmt.Fprintf(os.Stderr, "Serving logs on http://%s\n", listener.Addr())
+
+	lines := make(chan input.Line, webBatchSize)
+
+	go func() {
+		defer close(lines)
+
+		var exitErr *input.ExitError
+
+		for {
+			line, err := reader.ReadLine()
+			if errors.As(err, &exitErr) {
+				fmt.Fprintf(os.Stderr, "Process %s\n", exitErr.Error())
+				return
+			} else if errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+
+			lines <- line
+		}
+	}()
+
+	go ingest(processor, lock, server, lines)
+
+	err = config.Watch(func(cfg config.Config, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Config error: %s\n", err)
+			return
+		}
+
+		lock.Lock()
+		err = processor.Reconfigure(cfg)
+		if err == nil {
+			collect(processor)
+		}
+		lock.Unlock()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Config error: %s\n", err)
+			return
+		}
+
+		server.Update()
+		fmt.Fprintln(os.Stderr, "Config reloaded")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return server.Serve(listener)
+}
+
+// ingest processes lines in batches of whatever has already been read, sorting the groups and
+// applying the retention limits once per batch rather than for every line.
+func ingest(processor *dollop.Processor, lock sync.Locker, server *web.Server, lines <-chan input.Line) {
+	// Groups can age out while nothing is being read.
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				// Keep aging groups out after the input runs out.
+				lines = nil
+				continue
+			}
+
+			lock.Lock()
+			processor.ProcessAt(line.Source, line.Text, line.ReadTime())
+
+		batch:
+			for i := 1; i < webBatchSize; i++ {
+				select {
+				case line, ok := <-lines:
+					if !ok {
+						break batch
+					}
+
+					processor.ProcessAt(line.Source, line.Text, line.ReadTime())
+				default:
+					break batch
+				}
+			}
+
+			collect(processor)
+			lock.Unlock()
+
+			server.Update()
+
+		case <-ticker.C:
+			// The config can be reloaded, so it's checked each time.
+			lock.Lock()
+			aging := processor.Config().MaxAge > 0
+			if aging {
+				collect(processor)
+			}
+			lock.Unlock()
+
+			if aging {
+				server.Update()
+			}
+		}
+	}
+}
+
+// collect sorts the groups and applies the retention limits.
+func collect(processor *dollop.Processor) {
+	processor.Reorder()
+	processor.Evict(time.Now())
+	processor.Changed()
+}
@@ -1,174 +1,109 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"text/template"
+	"path/filepath"
+	"strings"
 
-	"github.com/elseano/dollop/internal/templating"
+	"github.com/elseano/dollop/pkg/dollop"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
-type Config struct {
-	LevelField     string        `yaml:"levelField"`
-	MessageField   string        `yaml:"messageField"`
-	TimestampField string        `yaml:"timestampField"`
-	Groups         []*GroupSpec  `yaml:"groups"`
-	Statuses       []*StatusSpec `yaml:"statuses"`
-	Tags           []*TagSpec    `yaml:"tags"`
-
-	LevelTmpl     *template.Template
-	MessageTmpl   *template.Template
-	TimestampTmpl *template.Template
-}
-
-type GroupSpec struct {
-	ValueField string     `yaml:"valueField"`
-	TitleField string     `yaml:"titleField"`
-	Tags       []*TagSpec `yaml:"tags"`
-	Name       string     `yaml:"name"`
-
-	TitleTmpl *template.Template
-	ValueTmpl *template.Template
-}
-
-type StatusSpec struct {
-	Display string `yaml:"display"`
-
-	DisplayTmpl *template.Template
-}
-
-type TagSpec struct {
-	Value string `yaml:"source"`
-	Key   string `yaml:"name"`
+// The config types belong to pkg/dollop, so the config file can drive a Processor directly. The
+// preset, if any, is read from viper separately.
+type (
+	Config           = dollop.Config
+	GroupSpec        = dollop.GroupSpec
+	StatusSpec       = dollop.StatusSpec
+	TagSpec          = dollop.TagSpec
+	FormatSpec       = dollop.FormatSpec
+	ContinuationSpec = dollop.ContinuationSpec
+)
 
-	ValueTmpl *template.Template
-	KeyTmpl   *template.Template
-}
+// fileErr is the error from the last attempt to read the config file, which Read returns so a
+// config file with a syntax error isn't silently replaced by the defaults.
+var fileErr error
 
-func (c *Config) PrepareTemplates() {
-	c.MessageTmpl = templating.BuildTemplate(c.MessageField)
-	c.TimestampTmpl = templating.BuildTemplate(c.TimestampField)
-	c.LevelTmpl = templating.BuildTemplate(c.LevelField)
+// ReadFile reads the config file viper has been pointed at. A config file which isn't found isn't an
+// error, as the defaults are used instead, but one which can't be read or parsed is.
+func ReadFile() error {
+	err := viper.ReadInConfig()
 
-	if c.Tags == nil {
-		c.Tags = []*TagSpec{}
+	var notFound viper.ConfigFileNotFoundError
+	if errors.As(err, &notFound) {
+		err = nil
 	}
 
-	for _, t := range c.Tags {
-		t.PrepareTemplates()
+	if err != nil {
+		err = fmt.Errorf("reading %s: %w", filepath.Base(viper.ConfigFileUsed()), err)
 	}
 
-	for _, g := range c.Groups {
-		g.ValueTmpl = templating.BuildTemplate(g.ValueField)
-		g.TitleTmpl = templating.BuildTemplate(g.TitleField)
-		if g.Tags == nil {
-			g.Tags = []*TagSpec{}
-		}
-
-		for _, t := range g.Tags {
-			t.PrepareTemplates()
-		}
-	}
+	fileErr = err
 
-	for _, s := range c.Statuses {
-		s.DisplayTmpl = templating.BuildTemplate(s.Display)
-	}
-}
-
-func (t *TagSpec) PrepareTemplates() {
-	t.KeyTmpl = templating.BuildTemplateText(t.Key)
-	if t.Value != "" {
-		t.ValueTmpl = templating.BuildTemplate(t.Value)
-	}
+	return err
 }
 
-func Get() (config Config) {
-	config = Config{
-		MessageField:   "msg",
-		TimestampField: "time",
-		LevelField:     "level",
-		Groups: []*GroupSpec{
-			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
-			{ValueField: "category", TitleField: "category", Name: "Category"},
-		},
-	}
-
-	if err := viper.Unmarshal(&config); err != nil {
-		log.Fatal("Error parsing config", err)
-	}
-
-	if err := config.Validate(); err != nil {
+// Get loads the config, exiting if it's invalid.
+func Get() Config {
+	config, err := Load()
+	if err != nil {
 		log.Fatalf("Config error: %s", err.Error())
 	}
 
-	config.PrepareTemplates()
-
-	return
+	return config
 }
 
-func (c Config) Validate() error {
-	if c.LevelField == "" {
-		return fmt.Errorf("'levelField' cannot be blank")
-	}
-
-	if c.MessageField == "" {
-		return fmt.Errorf("'messageField' cannot be blank")
-	}
-
-	if c.TimestampField == "" {
-		return fmt.Errorf("'timestampField' cannot be blank")
-	}
-
-	for i, g := range c.Groups {
-		if err := g.Validate(); err != nil {
-			return fmt.Errorf("group entry #%d: %w", i+1, err)
-		}
-	}
-
-	for i, s := range c.Statuses {
-		if err := s.Validate(); err != nil {
-			return fmt.Errorf("status entry #%d: %w", i+1, err)
-		}
+// Load reads the config, then validates it. It can be called again to pick up changes to the config
+// file.
+func Load() (Config, error) {
+	config, err := Read()
+	if err != nil {
+		return config, err
 	}
 
-	return nil
+	return config, config.Validate()
 }
 
-func (g GroupSpec) Validate() error {
-	if g.TitleField == "" {
-		return fmt.Errorf("'titleField' cannot be blank")
-	}
-
-	if g.ValueField == "" {
-		return fmt.Errorf("'valueField' cannot be blank")
-	}
+// Read reads the config from viper over the defaults, or over the preset it names, without validating
+// it. Lists and maps in the config replace the preset's, rather than being merged. Returns the error
+// from ReadFile if the config file couldn't be read.
+func Read() (config Config, err error) {
+	config = defaults()
 
-	if g.Name == "" {
-		return fmt.Errorf("'name' cannot be blank")
+	if fileErr != nil {
+		return config, fileErr
 	}
 
-	for i, t := range g.Tags {
-		if err := t.Validate(); err != nil {
-			return fmt.Errorf("tag %d: %w", i, err)
+	if name := viper.GetString("preset"); name != "" {
+		preset, ok := presets[name]
+		if !ok {
+			return config, fmt.Errorf("unknown preset %q, expected one of %s", name, strings.Join(Presets(), ", "))
 		}
+
+		config = preset()
 	}
 
-	return nil
-}
+	zeroFields := func(dc *mapstructure.DecoderConfig) { dc.ZeroFields = true }
 
-func (s StatusSpec) Validate() error {
-	if s.Display == "" {
-		return fmt.Errorf("'display' cannot be blank")
+	if err = viper.Unmarshal(&config, zeroFields); err != nil {
+		return config, fmt.Errorf("parsing config: %w", err)
 	}
 
-	return nil
+	return config, nil
 }
 
-func (s TagSpec) Validate() error {
-	if s.Key == "" {
-		return fmt.Errorf("'key' must be specified")
+func defaults() Config {
+	return Config{
+		MessageField:   "msg",
+		TimestampField: "time",
+		LevelField:     "level",
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
+			{ValueField: "category", TitleField: "category", Name: "Category"},
+		},
+		PinErrors: true,
 	}
-
-	return nil
 }
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDistinct caps how many distinct values are counted for each field while inferring a config.
+const maxDistinct = 10000
+
+// maxInferredGroups is how many of the candidate group fields are used. The rest are listed as
+// comments.
+const maxInferredGroups = 3
+
+var (
+	messageNames   = []string{"msg", "message", "@message", "log", "text", "event"}
+	levelNames     = []string{"level", "lvl", "severity", "log.level", "levelname", "level_name", "loglevel"}
+	timestampNames = []string{"time", "ts", "timestamp", "@timestamp", "datetime", "date", "t"}
+	groupNames     = []string{"request_id", "requestid", "trace_id", "traceid", "trace.id", "correlation_id", "correlationid", "job_id", "jobid", "session_id", "sessionid"}
+	errorNames     = []string{"error", "err", "exception", "stacktrace", "stack", "error.message"}
+	valueTagNames  = []string{"status", "status_code", "statuscode", "http.status_code", "duration", "duration_ms", "elapsed", "elapsed_ms", "latency", "latency_ms"}
+	levelWords     = []string{"trace", "debug", "info", "notice", "warn", "error", "err", "fatal", "panic", "crit"}
+
+	identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	idSuffix   = regexp.MustCompile(`(?i)(^|[_.-])id$|[a-z]Id$|[a-z]ID$`)
+	plainYAML  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+)
+
+// fieldStats describes how a field appeared across the sample records.
+type fieldStats struct {
+	// path joins the keys with dots. A flat key like "log.level" has the same path as a level nested
+	// in log, so keys tells them apart.
+	path     string
+	keys     []string
+	count    int
+	distinct map[string]int
+	strings  int
+	numbers  int
+	length   int
+	times    int
+}
+
+func (f *fieldStats) add(value interface{}) {
+	f.count++
+
+	switch v := value.(type) {
+	case string:
+		f.strings++
+		f.length += len(v)
+
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			f.times++
+		}
+	case float64, int:
+		f.numbers++
+	}
+
+	key := fmt.Sprint(value)
+	if _, ok := f.distinct[key]; ok || len(f.distinct) < maxDistinct {
+		f.distinct[key]++
+	}
+}
+
+// linesPerValue is how many lines share each value, on average.
+func (f *fieldStats) linesPerValue() float64 {
+	return float64(f.count) / float64(len(f.distinct))
+}
+
+// Suggestion is a starter config inferred from sample logs, with the reasons for each guess.
+type Suggestion struct {
+	// Lines is how many sample records were analysed.
+	Lines int
+
+	MessageField   string
+	LevelField     string
+	TimestampField string
+	Groups         []*GroupSpec
+	Tags           []*TagSpec
+
+	// Notes explain the guesses, keyed by the setting, such as "messageField" or "groups[0]".
+	Notes map[string]string
+	// Candidates describe other fields which could group lines, but weren't used.
+	Candidates []string
+}
+
+// Infer guesses the message, level and timestamp fields from sample records, along with fields
+// which look like IDs to group lines by, and fields worth tagging.
+func Infer(records []map[string]interface{}) Suggestion {
+	fields := map[string]*fieldStats{}
+
+	for _, record := range records {
+		collectFields(fields, nil, record)
+	}
+
+	s := Suggestion{
+		Lines:          len(records),
+		MessageField:   "msg",
+		LevelField:     "level",
+		TimestampField: "time",
+		Groups:         []*GroupSpec{},
+		Tags:           []*TagSpec{},
+		Notes:          map[string]string{},
+	}
+
+	used := map[string]bool{}
+
+	if f := s.pickMessage(fields); f != nil {
+		s.MessageField = fieldRef(f)
+		s.Notes["messageField"] = fmt.Sprintf("%s is in %s of lines, averaging %d characters.", f.path, s.percent(f.count), f.length/f.count)
+		used[f.path] = true
+	} else {
+		s.Notes["messageField"] = "No message field was found, so this is the default."
+	}
+
+	if f := s.pickLevel(fields); f != nil {
+		s.LevelField = fieldRef(f)
+		note := fmt.Sprintf("%s is in %s of lines, with values like %s.", f.path, s.percent(f.count), examples(f, 4))
+		if f.numbers > 0 {
+			note += " Numeric levels are shown as they are."
+		}
+		s.Notes["levelField"] = note
+		used[f.path] = true
+	} else {
+		s.Notes["levelField"] = "No level field was found, so this is the default."
+	}
+
+	if f := s.pickTimestamp(fields); f != nil {
+		s.TimestampField = fieldRef(f)
+		note := fmt.Sprintf("%s is in %s of lines.", f.path, s.percent(f.count))
+		if f.numbers == f.count {
+			note += " Its values are numbers, which are read as the time since the epoch."
+		} else if f.times < f.count {
+			note += " Not all of its values are RFC3339 times, so check timestampFormat."
+		}
+		s.Notes["timestampField"] = note
+		used[f.path] = true
+	} else {
+		s.Notes["timestampField"] = "No timestamp field was found, so this is the default. Lines are shown with the time they were read."
+	}
+
+	// Every line in a group needs a title, so the message is the safest choice.
+	title := s.MessageField
+
+	for _, f := range s.groupCandidates(fields, used) {
+		note := fmt.Sprintf("%s is in %s of lines, with %.1f lines per value.", f.path, s.percent(f.count), f.linesPerValue())
+
+		if len(s.Groups) == maxInferredGroups {
+			s.Candidates = append(s.Candidates, note)
+			continue
+		}
+
+		s.Notes[fmt.Sprintf("groups[%d]", len(s.Groups))] = note
+		s.Groups = append(s.Groups, &GroupSpec{ValueField: fieldRef(f), TitleField: title, Name: groupName(f.path)})
+		used[f.path] = true
+	}
+
+	s.inferTags(fields, used)
+
+	return s
+}
+
+// collectFields records each scalar value in a record, under the keys leading to it.
+func collectFields(fields map[string]*fieldStats, parents []string, record map[string]interface{}) {
+	for key, value := range record {
+		keys := append(append([]string{}, parents...), key)
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			collectFields(fields, keys, v)
+			continue
+		case []interface{}:
+			continue
+		}
+
+		id := strings.Join(keys, "\x00")
+
+		f, ok := fields[id]
+		if !ok {
+			f = &fieldStats{path: strings.Join(keys, "."), keys: keys, distinct: map[string]int{}}
+			fields[id] = f
+		}
+
+		f.add(value)
+	}
+}
+
+func (s Suggestion) percent(count int) string {
+	return fmt.Sprintf("%d%%", count*100/s.Lines)
+}
+
+// named returns the first of the names which is a field in at least half of the lines.
+func (s Suggestion) named(fields map[string]*fieldStats, names []string, accept func(*fieldStats) bool) *fieldStats {
+	for _, name := range names {
+		for _, f := range sortedFields(fields) {
+			if strings.EqualFold(f.path, name) && f.count*2 >= s.Lines && accept(f) {
+				return f
+			}
+		}
+	}
+
+	return nil
+}
+
+// best returns the field which scores highest, ignoring those scoring zero or less.
+func best(fields map[string]*fieldStats, score func(*fieldStats) float64) *fieldStats {
+	var result *fieldStats
+	top := 0.0
+
+	for _, f := range sortedFields(fields) {
+		if sc := score(f); sc > top {
+			result, top = f, sc
+		}
+	}
+
+	return result
+}
+
+func (s Suggestion) pickMessage(fields map[string]*fieldStats) *fieldStats {
+	mostlyStrings := func(f *fieldStats) bool { return f.strings*2 > f.count }
+
+	if f := s.named(fields, messageNames, mostlyStrings); f != nil {
+		return f
+	}
+
+	// Otherwise the longest text which is in most lines, and isn't a time.
+	return best(fields, func(f *fieldStats) float64 {
+		if f.count*2 < s.Lines || !mostlyStrings(f) || f.times > 0 {
+			return 0
+		}
+
+		return float64(f.length) / float64(f.count)
+	})
+}
+
+func (s Suggestion) pickLevel(fields map[string]*fieldStats) *fieldStats {
+	if f := s.named(fields, levelNames, func(*fieldStats) bool { return true }); f != nil {
+		return f
+	}
+
+	// Otherwise a field with a few distinct values, most of which look like levels.
+	return best(fields, func(f *fieldStats) float64 {
+		if f.count*2 < s.Lines || len(f.distinct) > 10 {
+			return 0
+		}
+
+		levels := 0
+		for value, n := range f.distinct {
+			if isLevelWord(value) {
+				levels += n
+			}
+		}
+
+		if levels*5 < f.count*4 {
+			return 0
+		}
+
+		return float64(f.count)
+	})
+}
+
+func (s Suggestion) pickTimestamp(fields map[string]*fieldStats) *fieldStats {
+	if f := s.named(fields, timestampNames, func(*fieldStats) bool { return true }); f != nil {
+		return f
+	}
+
+	// Otherwise the field with the most RFC3339 times.
+	return best(fields, func(f *fieldStats) float64 {
+		if f.times*5 < f.count*4 {
+			return 0
+		}
+
+		return float64(f.times)
+	})
+}
+
+// groupCandidates returns the fields which look like IDs and are shared by several lines, with the
+// well known names first, then the most common.
+func (s Suggestion) groupCandidates(fields map[string]*fieldStats, used map[string]bool) []*fieldStats {
+	candidates := []*fieldStats{}
+
+	for _, f := range sortedFields(fields) {
+		if used[f.path] || len(f.distinct) < 2 || f.linesPerValue() < 1.5 {
+			continue
+		}
+
+		if indexOf(groupNames, f.path) >= 0 || idSuffix.MatchString(lastSegment(f.path)) {
+			candidates = append(candidates, f)
+		}
+	}
+
+	rank := func(f *fieldStats) int {
+		if i := indexOf(groupNames, f.path); i >= 0 {
+			return i
+		}
+
+		return len(groupNames)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+
+		return a.count > b.count
+	})
+
+	return candidates
+}
+
+// inferTags tags lines which have an error, and shows the values of status and duration fields.
+func (s *Suggestion) inferTags(fields map[string]*fieldStats, used map[string]bool) {
+	for _, f := range sortedFields(fields) {
+		if used[f.path] {
+			continue
+		}
+
+		name := lastSegment(f.path)
+		path := fmt.Sprintf("tags[%d]", len(s.Tags))
+
+		switch {
+		case indexOf(errorNames, f.path) >= 0 && f.count < s.Lines:
+			s.Notes[path] = fmt.Sprintf("%s is in %s of lines.", f.path, s.percent(f.count))
+			s.Tags = append(s.Tags, &TagSpec{Key: fmt.Sprintf("{{ if %s }}%s{{ end }}", templateRef(f), name)})
+
+		case indexOf(valueTagNames, f.path) >= 0:
+			s.Notes[path] = fmt.Sprintf("%s is in %s of lines, with values like %s.", f.path, s.percent(f.count), examples(f, 3))
+			s.Tags = append(s.Tags, &TagSpec{Key: name, Value: fieldRef(f)})
+		}
+	}
+}
+
+// WriteYAML writes the suggestion as a commented config file.
+func (s Suggestion) WriteYAML(w io.Writer) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "# Inferred by \"dollop config init\" from %d sample lines. Check the guesses below, then\n", s.Lines)
+	fmt.Fprintf(b, "# try it against your logs with \"dollop config check sample.log\".\n")
+
+	writeSetting := func(key string, value string) {
+		fmt.Fprintf(b, "\n# %s\n%s: %s\n", s.Notes[key], key, yamlString(value))
+	}
+
+	writeSetting("messageField", s.MessageField)
+	writeSetting("levelField", s.LevelField)
+	writeSetting("timestampField", s.TimestampField)
+
+	fmt.Fprintf(b, "\n# Lines are added to the first group whose valueField and titleField they have.\n")
+	if len(s.Groups) == 0 {
+		fmt.Fprintf(b, "# No fields looked like IDs shared by several lines, so every line is ungrouped.\n")
+		fmt.Fprintf(b, "groups: []\n")
+	} else {
+		fmt.Fprintf(b, "groups:\n")
+	}
+
+	for i, g := range s.Groups {
+		fmt.Fprintf(b, "  # %s\n", s.Notes[fmt.Sprintf("groups[%d]", i)])
+		fmt.Fprintf(b, "  - valueField: %s\n", yamlString(g.ValueField))
+		fmt.Fprintf(b, "    titleField: %s\n", yamlString(g.TitleField))
+		fmt.Fprintf(b, "    name: %s\n", yamlString(g.Name))
+	}
+
+	if len(s.Candidates) > 0 {
+		fmt.Fprintf(b, "  # Other fields which could group lines:\n")
+		for _, c := range s.Candidates {
+			fmt.Fprintf(b, "  #   %s\n", c)
+		}
+	}
+
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(b, "\ntags:\n")
+	}
+
+	for i, t := range s.Tags {
+		fmt.Fprintf(b, "  # %s\n", s.Notes[fmt.Sprintf("tags[%d]", i)])
+		fmt.Fprintf(b, "  - key: %s\n", yamlString(t.Key))
+		if t.Value != "" {
+			fmt.Fprintf(b, "    value: %s\n", yamlString(t.Value))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// fieldRef refers to a field by its path, or with a template when the path can't be used directly,
+// such as @timestamp or a flat key containing dots.
+func fieldRef(f *fieldStats) string {
+	if plainKeys(f.keys) {
+		return f.path
+	}
+
+	return "{{ " + templateRef(f) + " }}"
+}
+
+// templateRef is the template expression for a field's value.
+func templateRef(f *fieldStats) string {
+	if plainKeys(f.keys) {
+		return "." + f.path
+	}
+
+	keys := make([]string, len(f.keys))
+	for i, k := range f.keys {
+		keys[i] = strconv.Quote(k)
+	}
+
+	return "index . " + strings.Join(keys, " ")
+}
+
+// plainKeys returns true when every key can be written after a dot in a template.
+func plainKeys(keys []string) bool {
+	for _, k := range keys {
+		if !identifier.MatchString(k) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// groupName names a group after its field, such as Request for request_id.
+func groupName(path string) string {
+	name := lastSegment(path)
+	if strings.EqualFold(name, "id") && strings.Contains(path, ".") {
+		name = lastSegment(path[:strings.LastIndex(path, ".")])
+	} else if strings.HasSuffix(strings.ToLower(name), "id") {
+		name = strings.TrimRight(name[:len(name)-2], "_.-")
+	}
+
+	if name == "" {
+		return "Group"
+	}
+
+	name = strings.ReplaceAll(name, "_", " ")
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// yamlString quotes a value when it isn't safe to write plainly.
+func yamlString(value string) string {
+	if plainYAML.MatchString(value) {
+		return value
+	}
+
+	return strconv.Quote(value)
+}
+
+func examples(f *fieldStats, n int) string {
+	values := make([]string, 0, len(f.distinct))
+	for v := range f.distinct {
+		values = append(values, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if f.distinct[values[i]] != f.distinct[values[j]] {
+			return f.distinct[values[i]] > f.distinct[values[j]]
+		}
+
+		return values[i] < values[j]
+	})
+
+	if len(values) > n {
+		values = values[:n]
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func isLevelWord(value string) bool {
+	value = strings.ToLower(value)
+
+	for _, word := range levelWords {
+		if strings.HasPrefix(value, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedFields(fields map[string]*fieldStats) []*fieldStats {
+	result := make([]*fieldStats, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, f)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].path < result[j].path })
+
+	return result
+}
+
+func lastSegment(path string) string {
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+func indexOf(names []string, path string) int {
+	for i, name := range names {
+		if strings.EqualFold(name, path) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,183 @@
+package config
+
+import "sort"
+
+// presets are configs for common loggers, chosen with "preset: name" or --preset. Each is built
+// fresh, so the specs aren't shared between configs.
+var presets = map[string]func() Config{
+	"zap":     zapPreset,
+	"logrus":  logrusPreset,
+	"slog":    slogPreset,
+	"pino":    pinoPreset,
+	"bunyan":  bunyanPreset,
+	"lograge": logragePreset,
+	"ecs":     ecsPreset,
+}
+
+// Presets returns the names of the built in presets.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// numericLevels are the levels used by pino and bunyan.
+func numericLevels() map[string]string {
+	return map[string]string{
+		"10": "trace",
+		"20": "debug",
+		"30": "info",
+		"40": "warning",
+		"50": "error",
+		"60": "fatal",
+	}
+}
+
+// presenceTag tags lines which have a field, such as an error.
+func presenceTag(field string, name string) *TagSpec {
+	return &TagSpec{Key: "{{ if ." + field + " }}" + name + "{{ end }}"}
+}
+
+// zap's production encoder writes the time as seconds since the epoch.
+func zapPreset() Config {
+	return Config{
+		MessageField:    "msg",
+		LevelField:      "level",
+		TimestampField:  "ts",
+		TimestampFormat: "unix",
+		Levels:          map[string]string{"warn": "warning", "dpanic": "fatal", "panic": "fatal"},
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
+			{ValueField: "trace_id", TitleField: "msg", Name: "Trace"},
+			{ValueField: "logger", TitleField: "logger", Name: "Logger"},
+		},
+		Tags: []*TagSpec{
+			presenceTag("error", "error"),
+			{Key: "caller", Value: "caller"},
+		},
+		PinErrors: true,
+	}
+}
+
+func logrusPreset() Config {
+	return Config{
+		MessageField:   "msg",
+		LevelField:     "level",
+		TimestampField: "time",
+		Levels:         map[string]string{"panic": "fatal"},
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
+			{ValueField: "category", TitleField: "category", Name: "Category"},
+		},
+		Tags:      []*TagSpec{presenceTag("error", "error")},
+		PinErrors: true,
+	}
+}
+
+// slog's JSON handler writes levels in capitals.
+func slogPreset() Config {
+	return Config{
+		MessageField:   "msg",
+		LevelField:     "level",
+		TimestampField: "time",
+		Levels:         map[string]string{"debug": "debug", "info": "info", "warn": "warning", "error": "error"},
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
+			{ValueField: "trace_id", TitleField: "msg", Name: "Trace"},
+		},
+		Tags: []*TagSpec{
+			presenceTag("err", "error"),
+			presenceTag("error", "error"),
+		},
+		PinErrors: true,
+	}
+}
+
+// pino writes numeric levels, and the time in milliseconds. pino-http adds the request under req.
+func pinoPreset() Config {
+	return Config{
+		MessageField:    "msg",
+		LevelField:      "level",
+		TimestampField:  "time",
+		TimestampFormat: "unix_ms",
+		Levels:          numericLevels(),
+		Groups: []*GroupSpec{
+			{ValueField: "reqId", TitleField: "msg", Name: "Request"},
+			{ValueField: "req.id", TitleField: "msg", Name: "Request"},
+		},
+		Tags: []*TagSpec{
+			presenceTag("err", "error"),
+			{Key: "status", Value: "res.statusCode"},
+			{Key: "duration", Value: "{{ .responseTime }}ms"},
+		},
+		PinErrors: true,
+	}
+}
+
+// bunyan writes numeric levels like pino, but an RFC3339 time.
+func bunyanPreset() Config {
+	return Config{
+		MessageField:   "msg",
+		LevelField:     "level",
+		TimestampField: "time",
+		Levels:         numericLevels(),
+		Groups: []*GroupSpec{
+			{ValueField: "req_id", TitleField: "msg", Name: "Request"},
+			{ValueField: "component", TitleField: "component", Name: "Component"},
+		},
+		Tags: []*TagSpec{
+			presenceTag("err", "error"),
+			{Key: "status", Value: "res.statusCode"},
+		},
+		PinErrors: true,
+	}
+}
+
+// lograge writes one line per Rails request, without a message or level, so they're made from
+// the request. Its durations are in milliseconds.
+func logragePreset() Config {
+	return Config{
+		MessageField:   "{{ .method }} {{ .path }}",
+		LevelField:     "{{ with .level }}{{ . }}{{ else }}{{ with .status }}{{ if ge . 500.0 }}error{{ else if ge . 400.0 }}warning{{ else }}info{{ end }}{{ end }}{{ end }}",
+		TimestampField: "time",
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "{{ .method }} {{ .path }}", Name: "Request"},
+			{ValueField: "{{ .controller }}#{{ .action }}", TitleField: "{{ .controller }}#{{ .action }}", Name: "Action"},
+		},
+		Tags: []*TagSpec{
+			{Key: "status", Value: "status"},
+			{Key: "duration", Value: "{{ .duration }}ms"},
+			{Key: "db", Value: "{{ .db }}ms"},
+			{Key: "view", Value: "{{ .view }}ms"},
+			presenceTag("error", "error"),
+		},
+		PinErrors: true,
+	}
+}
+
+// Elastic Common Schema loggers write some fields as flat dotted keys, like "log.level", and nest
+// others, which field names reach either way. Its event.duration is in nanoseconds.
+func ecsPreset() Config {
+	return Config{
+		MessageField:   "message",
+		LevelField:     "log.level",
+		TimestampField: `{{ index . "@timestamp" }}`,
+		Levels:         map[string]string{"warn": "warning", "critical": "fatal"},
+		Groups: []*GroupSpec{
+			{ValueField: "trace.id", TitleField: "message", Name: "Trace"},
+			{ValueField: "transaction.id", TitleField: "message", Name: "Transaction"},
+			{ValueField: "service.name", TitleField: "service.name", Name: "Service"},
+		},
+		Tags: []*TagSpec{
+			presenceTag("error", "error"),
+			{Key: "status", Value: "http.response.status_code"},
+			{Key: "duration", Value: `{{ with Field . "event.duration" }}{{ FormatSeconds (div . 1000000000) }}{{ end }}`},
+		},
+		PinErrors: true,
+	}
+}
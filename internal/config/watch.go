@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// settleDelay waits for a burst of writes to the config file to finish, as editors often save in
+// several steps.
+const settleDelay = 100 * time.Millisecond
+
+var (
+	watchMutex sync.Mutex
+	watching   bool
+	// watchers are called in turn whenever the config changes.
+	watchers []func(Config, error)
+)
+
+// Watch calls fn with the reloaded config whenever the config file viper read changes, or with the
+// error if it can't be loaded. Does nothing when no config file was read. It can be called more than
+// once, and each fn is given a config of its own, which it can prepare without affecting the others.
+//
+// viper.WatchConfig logs read errors rather than returning them, and stops watching when the file is
+// removed, so the file's directory is watched here instead.
+func Watch(fn func(Config, error)) error {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return nil
+	}
+
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	if watching {
+		watchers = append(watchers, fn)
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("watching config: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watching config: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(abs)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watching config: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var settled <-chan time.Time
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) == abs && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					settled = time.After(settleDelay)
+				}
+
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+			case <-settled:
+				settled = nil
+
+				watchMutex.Lock()
+				fns := watchers
+				watchMutex.Unlock()
+
+				if err := ReadFile(); err != nil {
+					for _, fn := range fns {
+						fn(Config{}, err)
+					}
+
+					continue
+				}
+
+				for _, fn := range fns {
+					fn(Load())
+				}
+			}
+		}
+	}()
+
+	watching = true
+	watchers = append(watchers, fn)
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pollInterval is how often followed files are checked for changes when no filesystem events arrive.
+const pollInterval = time.Second
+
+// followReader reads every source concurrently, continuing to read files as they grow.
+type followReader struct {
+	lines     chan Line
+	done      chan struct{}
+	watcher   *fsnotify.Watcher
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// follower tails a single file, reopening it by path when it is rotated or truncated.
+type follower struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial string
+	wake    chan struct{}
+}
+
+func openFollowing(paths []string) (Reader, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watching files: %w", err)
+	}
+
+	r := &followReader{
+		lines:   make(chan Line),
+		done:    make(chan struct{}),
+		watcher: watcher,
+	}
+
+	followers := map[string]*follower{}
+	watchedDirs := map[string]struct{}{}
+	readStdin := false
+
+	for _, path := range paths {
+		if path == "-" {
+			readStdin = true
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			r.closeFollowers(followers)
+			return nil, fmt.Errorf("opening %s: %w", path, err)
+		}
+
+		f := &follower{path: path, wake: make(chan struct{}, 1)}
+		if err := f.open(); err != nil {
+			r.closeFollowers(followers)
+			return nil, fmt.Errorf("opening %s: %w", path, err)
+		}
+
+		followers[abs] = f
+
+		// Watch the directory rather than the file, so a file recreated after rotation is still seen.
+		dir := filepath.Dir(abs)
+		if _, ok := watchedDirs[dir]; !ok {
+			if err := watcher.Add(dir); err != nil {
+				r.closeFollowers(followers)
+				return nil, fmt.Errorf("watching %s: %w", dir, err)
+			}
+			watchedDirs[dir] = struct{}{}
+		}
+	}
+
+	if readStdin {
+		r.wg.Add(1)
+		go r.readStdin()
+	}
+
+	for _, f := range followers {
+		r.wg.Add(1)
+		go f.run(r)
+	}
+
+	go r.dispatchEvents(followers)
+
+	go func() {
+		r.wg.Wait()
+		close(r.lines)
+	}()
+
+	return r, nil
+}
+
+func (r *followReader) closeFollowers(followers map[string]*follower) {
+	for _, f := range followers {
+		f.close()
+	}
+
+	r.watcher.Close()
+}
+
+// ReadLine blocks until any source produces a line.
+func (r *followReader) ReadLine() (Line, error) {
+	line, ok := <-r.lines
+	if !ok {
+		return Line{}, io.EOF
+	}
+
+	return line, nil
+}
+
+// Close stops following all files.
+func (r *followReader) Close() (err error) {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		err = r.watcher.Close()
+	})
+
+	return
+}
+
+func (r *followReader) send(line Line) bool {
+	select {
+	case r.lines <- line:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+func (r *followReader) readStdin() {
+	defer r.wg.Done()
+
+	reader := bufio.NewReaderSize(os.Stdin, readerSize)
+
+	for {
+		text, err := reader.ReadString('\n')
+		if text != "" && !r.send(Line{Text: text}) {
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *followReader) dispatchEvents(followers map[string]*follower) {
+	for {
+		select {
+		case ev, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if f, ok := followers[filepath.Clean(ev.Name)]; ok {
+				f.notify()
+			}
+
+		case _, ok := <-r.watcher.Errors:
+			// Missed events are picked up by polling, so errors are otherwise ignored.
+			if !ok {
+				return
+			}
+
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+	f.reader = bufio.NewReaderSize(file, readerSize)
+	f.offset = 0
+	f.partial = ""
+
+	return nil
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *follower) notify() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *follower) run(r *followReader) {
+	defer r.wg.Done()
+	defer f.close()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !f.readAvailable(r) {
+			return
+		}
+
+		more, ok := f.checkRotation(r)
+		if !ok {
+			return
+		}
+
+		if more {
+			continue
+		}
+
+		select {
+		case <-f.wake:
+		case <-ticker.C:
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// readAvailable sends every complete line currently in the file. Partial lines are held back
+// until the rest of the line is written. Returns false when the reader has been closed.
+func (f *follower) readAvailable(r *followReader) bool {
+	if f.file == nil {
+		return true
+	}
+
+	for {
+		text, err := f.reader.ReadString('\n')
+		f.offset += int64(len(text))
+
+		if err != nil {
+			f.partial += text
+
+			if !errors.Is(err, io.EOF) {
+				f.close()
+			}
+
+			return true
+		}
+
+		if !r.send(Line{Source: f.path, Text: f.partial + text}) {
+			return false
+		}
+
+		f.partial = ""
+	}
+}
+
+// checkRotation reopens the file if the path now points to a different file, and starts from the
+// beginning again if the file has been truncated. Whatever is left of the old file is sent first,
+// including a last line without a newline, which won't be finished now. Returns whether there may be
+// new content to read, and false for ok when the reader has been closed.
+func (f *follower) checkRotation(r *followReader) (more bool, ok bool) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		// Moved away and not yet recreated. Keep reading the old file until it is.
+		return false, true
+	}
+
+	if f.file == nil {
+		if !f.flushPartial(r) {
+			return false, false
+		}
+
+		return f.open() == nil, true
+	}
+
+	current, err := f.file.Stat()
+	if err != nil || !os.SameFile(info, current) {
+		if !f.readAvailable(r) || !f.flushPartial(r) {
+			return false, false
+		}
+
+		f.close()
+		return f.open() == nil, true
+	}
+
+	if info.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err == nil {
+			if !f.flushPartial(r) {
+				return false, false
+			}
+
+			f.reader.Reset(f.file)
+			f.offset = 0
+			return true, true
+		}
+	}
+
+	return false, true
+}
+
+// flushPartial sends a line which was never finished. Returns false when the reader has been closed.
+func (f *follower) flushPartial(r *followReader) bool {
+	if f.partial == "" {
+		return true
+	}
+
+	text := f.partial
+	f.partial = ""
+
+	return r.send(Line{Source: f.path, Text: text})
+}
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const readerSize = 1048576
+
+// Line is a single line of raw log output, along with the name of the source it was read from.
+type Line struct {
+	Source string
+	Text   string
+	// Time is when the line was originally read, for lines replayed from a recording. It's zero
+	// for lines being read now.
+	Time time.Time
+}
+
+// ReadTime returns when the line was read.
+func (l Line) ReadTime() time.Time {
+	if l.Time.IsZero() {
+		return time.Now()
+	}
+
+	return l.Time
+}
+
+// Reader produces lines of log output. ReadLine blocks until a line is available, and returns
+// io.EOF once there's nothing left to read.
+type Reader interface {
+	ReadLine() (Line, error)
+	Close() error
+}
+
+type source struct {
+	name   string
+	reader *bufio.Reader
+	closer io.Closer
+}
+
+// sequentialReader reads lines from one or more sources, one after the other.
+type sequentialReader struct {
+	sources []*source
+	current int
+}
+
+// Open creates a Reader over the given paths. A path of "-", or no paths at all, reads from stdin.
+// When follow is set, files continue to be read as they grow, and are reopened if rotated.
+func Open(paths []string, follow bool) (Reader, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	if follow {
+		return openFollowing(paths)
+	}
+
+	r := &sequentialReader{}
+
+	for _, path := range paths {
+		if path == "-" {
+			r.sources = append(r.sources, &source{reader: bufio.NewReaderSize(os.Stdin, readerSize)})
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("opening %s: %w", path, err)
+		}
+
+		r.sources = append(r.sources, &source{name: path, reader: bufio.NewReaderSize(f, readerSize), closer: f})
+	}
+
+	return r, nil
+}
+
+// ReadLine returns the next line, moving on to the next source once the current one is exhausted.
+// io.EOF is returned once every source has been read.
+func (r *sequentialReader) ReadLine() (Line, error) {
+	for r.current < len(r.sources) {
+		src := r.sources[r.current]
+		text, err := src.reader.ReadString('\n')
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Line{}, err
+		}
+
+		if err != nil {
+			r.current++
+		}
+
+		if text != "" {
+			return Line{Source: src.name, Text: text}, nil
+		}
+	}
+
+	return Line{}, io.EOF
+}
+
+// Close closes any files opened by the Reader.
+func (r *sequentialReader) Close() error {
+	var result error
+
+	for _, src := range r.sources {
+		if src.closer != nil {
+			if err := src.closer.Close(); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+
+	return result
+}
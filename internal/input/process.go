@@ -0,0 +1,261 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// stopTimeout is how long a child process is given to exit after being interrupted, before it is killed.
+	stopTimeout = 5 * time.Second
+
+	// killTimeout is how long the output of a killed process is read for, before giving up on
+	// anything it left running which still holds the output open.
+	killTimeout = time.Second
+)
+
+// ExitError is returned from Process.ReadLine once the child process has exited, and all of its output read.
+type ExitError struct {
+	Code  int
+	State string
+}
+
+func (e *ExitError) Error() string {
+	if e.Code >= 0 {
+		return fmt.Sprintf("exited with code %d", e.Code)
+	}
+
+	return fmt.Sprintf("exited (%s)", e.State)
+}
+
+// Restarter is implemented by readers whose source can be relaunched.
+type Restarter interface {
+	Restart() error
+}
+
+type processEvent struct {
+	line Line
+	exit *ExitError
+}
+
+type processRun struct {
+	cmd     *exec.Cmd
+	pipes   []io.Closer
+	done    chan struct{}
+	stopped bool
+}
+
+// Process runs a command and reads lines from its stdout and stderr, which are reported as separate sources.
+type Process struct {
+	name string
+	args []string
+
+	events    chan processEvent
+	closed    chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+	run       *processRun
+}
+
+// StartProcess launches the command, reading its output until it exits.
+func StartProcess(name string, args ...string) (*Process, error) {
+	p := &Process{
+		name:   name,
+		args:   args,
+		events: make(chan processEvent),
+		closed: make(chan struct{}),
+	}
+
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// String returns the command line being run.
+func (p *Process) String() string {
+	return strings.Join(append([]string{p.name}, p.args...), " ")
+}
+
+func (p *Process) start() error {
+	cmd := exec.Command(p.name, p.args...)
+	ownProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", p.name, err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", p.name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", p.name, err)
+	}
+
+	run := &processRun{cmd: cmd, pipes: []io.Closer{stdout, stderr}, done: make(chan struct{})}
+
+	p.mu.Lock()
+	p.run = run
+	p.mu.Unlock()
+
+	go p.wait(run, stdout, stderr)
+
+	return nil
+}
+
+func (p *Process) wait(run *processRun, stdout io.Reader, stderr io.Reader) {
+	defer close(run.done)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go p.readStream("stdout", stdout, &wg)
+	go p.readStream("stderr", stderr, &wg)
+
+	// All output has to be read before waiting, otherwise the pipes are closed underneath the readers.
+	wg.Wait()
+	run.cmd.Wait()
+
+	p.mu.Lock()
+	stopped := run.stopped
+	p.mu.Unlock()
+
+	if !stopped {
+		state := run.cmd.ProcessState
+		p.send(processEvent{exit: &ExitError{Code: state.ExitCode(), State: state.String()}})
+	}
+}
+
+// send delivers an event to ReadLine, unless the Process has been closed and nobody is reading.
+func (p *Process) send(ev processEvent) bool {
+	select {
+	case p.events <- ev:
+		return true
+	case <-p.closed:
+		return false
+	}
+}
+
+func (p *Process) readStream(name string, r io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	reader := bufio.NewReaderSize(r, readerSize)
+
+	for {
+		text, err := reader.ReadString('\n')
+		if text != "" && !p.send(processEvent{line: Line{Source: name, Text: text}}) {
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadLine returns the next line written by the child process. Once the process has exited,
+// an *ExitError is returned. Reading may continue after a Restart.
+// After Close, io.EOF is returned.
+func (p *Process) ReadLine() (Line, error) {
+	select {
+	case ev := <-p.events:
+		if ev.exit != nil {
+			return Line{}, ev.exit
+		}
+
+		return ev.line, nil
+
+	case <-p.closed:
+		return Line{}, io.EOF
+	}
+}
+
+// Signal sends a signal to the child process and everything it launched, if it's running.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	run := p.run
+	p.mu.Unlock()
+
+	if run == nil {
+		return nil
+	}
+
+	select {
+	case <-run.done:
+		return nil
+	default:
+		return signalGroup(run.cmd, sig)
+	}
+}
+
+// Restart stops the child process if it's still running, and launches it again.
+func (p *Process) Restart() error {
+	p.stop()
+	return p.start()
+}
+
+// Close stops the child process. Any remaining output is discarded.
+func (p *Process) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		p.stop()
+	})
+
+	return nil
+}
+
+// stop interrupts the current run, killing it if it doesn't exit in time. Output written while
+// stopping is still delivered, but the exit itself isn't reported. The whole process group is
+// signalled, and if something still holds the output open after the kill, it stops being read.
+func (p *Process) stop() {
+	p.mu.Lock()
+	run := p.run
+	if run != nil {
+		run.stopped = true
+	}
+	p.mu.Unlock()
+
+	if run == nil {
+		return
+	}
+
+	select {
+	case <-run.done:
+		return
+	default:
+	}
+
+	if err := signalGroup(run.cmd, os.Interrupt); err != nil {
+		signalGroup(run.cmd, os.Kill)
+	}
+
+	select {
+	case <-run.done:
+		return
+	case <-time.After(stopTimeout):
+		signalGroup(run.cmd, os.Kill)
+	}
+
+	select {
+	case <-run.done:
+		return
+	case <-time.After(killTimeout):
+	}
+
+	// Closing the pipes releases the readers, so the process can be waited for.
+	for _, pipe := range run.pipes {
+		pipe.Close()
+	}
+
+	<-run.done
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package input
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ownProcessGroup starts the command in a process group of its own, so it can be signalled along
+// with everything it launches, such as the children of sh -c, npm or foreman.
+func ownProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup sends a signal to the command's process group.
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package input
+
+import (
+	"os"
+	"os/exec"
+)
+
+// ownProcessGroup does nothing on Windows, which has no process groups to signal.
+func ownProcessGroup(cmd *exec.Cmd) {}
+
+// signalGroup sends a signal to the command itself, as Windows can only kill a process.
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	if sig == os.Kill {
+		return cmd.Process.Kill()
+	}
+
+	return cmd.Process.Signal(sig)
+}
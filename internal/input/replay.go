@@ -0,0 +1,304 @@
+package input
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrRewound is returned by a Replay's ReadLine after it seeks backwards. Everything read so far
+// should be dropped, as the lines are read again from the start, up to where the seek landed.
+var ErrRewound = errors.New("replay rewound")
+
+// Replayer is implemented by readers which pace lines by their timestamps, and can be controlled
+// while they do.
+type Replayer interface {
+	// TogglePause stops or restarts the replay, returning true when it's now paused.
+	TogglePause() bool
+	// Step lets the next line through while paused.
+	Step()
+	// Seek skips forward, letting through every line up to that far ahead without waiting. A
+	// negative duration rewinds, so ReadLine returns ErrRewound and then starts again.
+	Seek(d time.Duration)
+	// SetSpeed changes how many times faster than the original the replay runs.
+	SetSpeed(speed float64)
+	// Status returns the speed, the time of the latest line let through, and whether it's paused.
+	Status() (speed float64, position time.Time, paused bool)
+}
+
+// Replay reads lines from another Reader, holding each one back until as long after the previous
+// one as their timestamps are apart, divided by the speed. Lines without a timestamp aren't delayed.
+// Every line read is kept, so the replay can be rewound, and after the input runs out ReadLine
+// waits for a rewind rather than returning the error again, until the Replay is closed.
+type Replay struct {
+	reader    Reader
+	timestamp func(Line) (time.Time, bool)
+
+	// history is every line read so far, and next is the position in it to read from.
+	history []Line
+	next    int
+	rewound bool
+	// end is the error which ended the input, once it has been returned.
+	end error
+
+	mutex    sync.Mutex
+	speed    float64
+	paused   bool
+	steps    int
+	position time.Time
+	lastWall time.Time
+	seekTo   time.Time
+
+	wake   chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewReplay creates a Replay, which uses timestamp to find when each line was originally logged.
+func NewReplay(reader Reader, timestamp func(Line) (time.Time, bool), speed float64) *Replay {
+	return &Replay{
+		reader:    reader,
+		timestamp: timestamp,
+		speed:     speed,
+		wake:      make(chan struct{}, 1),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (r *Replay) ReadLine() (Line, error) {
+	line, err := r.nextLine()
+	if err != nil {
+		return line, err
+	}
+
+	t, ok := r.timestamp(line)
+	if !ok {
+		return line, nil
+	}
+
+	if err := r.wait(t); err != nil {
+		return Line{}, err
+	}
+
+	return line, nil
+}
+
+// nextLine returns the next line from the history, or from the reader once the history has been
+// replayed.
+func (r *Replay) nextLine() (Line, error) {
+	for {
+		select {
+		case <-r.closed:
+			return Line{}, os.ErrClosed
+		default:
+		}
+
+		r.mutex.Lock()
+
+		if r.rewound {
+			r.rewound = false
+			r.mutex.Unlock()
+			return Line{}, ErrRewound
+		}
+
+		if r.next < len(r.history) {
+			line := r.history[r.next]
+			r.next++
+			r.mutex.Unlock()
+			return line, nil
+		}
+
+		if r.end == nil {
+			break
+		}
+
+		r.mutex.Unlock()
+
+		// Everything has been replayed, so only a rewind brings more.
+		select {
+		case <-r.wake:
+		case <-r.closed:
+			return Line{}, os.ErrClosed
+		}
+	}
+
+	r.mutex.Unlock()
+
+	line, err := r.reader.ReadLine()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err != nil {
+		var exitErr *ExitError
+		if !errors.As(err, &exitErr) {
+			r.end = err
+		}
+
+		return line, err
+	}
+
+	r.history = append(r.history, line)
+
+	// The line is read again after the rewind, from the history.
+	if r.rewound {
+		r.rewound = false
+		return Line{}, ErrRewound
+	}
+
+	r.next++
+
+	return line, nil
+}
+
+// wait blocks until the line logged at t is due.
+func (r *Replay) wait(t time.Time) error {
+	for {
+		r.mutex.Lock()
+
+		// The line being held back is read again after the rewind, from the history.
+		if r.rewound {
+			r.rewound = false
+			r.mutex.Unlock()
+			return ErrRewound
+		}
+
+		if r.position.IsZero() || !t.After(r.position) || !t.After(r.seekTo) {
+			r.advance(t)
+			r.mutex.Unlock()
+			return nil
+		}
+
+		if r.paused && r.steps > 0 {
+			r.steps--
+			r.advance(t)
+			r.mutex.Unlock()
+			return nil
+		}
+
+		var timer <-chan time.Time
+
+		if !r.paused {
+			due := r.lastWall.Add(time.Duration(float64(t.Sub(r.position)) / r.speed))
+
+			wait := time.Until(due)
+			if wait <= 0 {
+				r.advance(t)
+				r.mutex.Unlock()
+				return nil
+			}
+
+			timer = time.After(wait)
+		}
+
+		r.mutex.Unlock()
+
+		select {
+		case <-timer:
+		case <-r.wake:
+		case <-r.closed:
+			return os.ErrClosed
+		}
+	}
+}
+
+func (r *Replay) advance(t time.Time) {
+	if t.After(r.position) {
+		r.position = t
+	}
+
+	r.lastWall = time.Now()
+}
+
+// control changes the replay's state, waking any line being held back so it's rescheduled.
+func (r *Replay) control(fn func()) {
+	r.mutex.Lock()
+	fn()
+	r.lastWall = time.Now()
+	r.mutex.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Replay) TogglePause() bool {
+	var paused bool
+
+	r.control(func() {
+		r.paused = !r.paused
+		r.steps = 0
+		paused = r.paused
+	})
+
+	return paused
+}
+
+func (r *Replay) Step() {
+	r.control(func() { r.steps++ })
+}
+
+func (r *Replay) Seek(d time.Duration) {
+	r.control(func() {
+		if d < 0 {
+			r.rewind(r.position.Add(d))
+			return
+		}
+
+		r.seekTo = r.position.Add(d)
+		if r.paused {
+			// Show where the seek landed, even if nothing was logged in between.
+			r.position = r.seekTo
+		}
+	})
+}
+
+// rewind starts reading from the start of the history again, letting everything up to target through
+// without waiting.
+func (r *Replay) rewind(target time.Time) {
+	if r.position.IsZero() || len(r.history) == 0 {
+		return
+	}
+
+	r.next = 0
+	r.rewound = true
+	r.steps = 0
+	r.seekTo = target
+	r.position = target
+
+	if first, ok := r.firstTimestamp(); !ok || !target.After(first) {
+		// Rewound to the start, so the first line is let through straight away, as it was originally.
+		r.seekTo = time.Time{}
+		r.position = time.Time{}
+	}
+}
+
+// firstTimestamp returns the timestamp of the first line which has one.
+func (r *Replay) firstTimestamp() (time.Time, bool) {
+	for _, line := range r.history {
+		if t, ok := r.timestamp(line); ok {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r *Replay) SetSpeed(speed float64) {
+	r.control(func() { r.speed = speed })
+}
+
+func (r *Replay) Status() (float64, time.Time, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.speed, r.position, r.paused
+}
+
+func (r *Replay) Close() error {
+	r.once.Do(func() { close(r.closed) })
+
+	return r.reader.Close()
+}
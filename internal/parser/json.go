@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JSON parses the JSON object starting at the first brace in the line. Anything before the brace is ignored.
+func JSON(line string) (map[string]interface{}, error) {
+	jsonIdx := strings.Index(line, "{")
+	if jsonIdx == -1 {
+		return nil, ErrUnrecognised
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal([]byte(line[jsonIdx:]), &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
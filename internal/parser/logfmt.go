@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Logfmt parses a line of key=value pairs, as written by slog's text handler, logrus and Heroku.
+// Quoted values are kept as strings, while unquoted numbers and booleans are converted so that
+// templates see the same types they would for JSON. Lines which aren't entirely key=value pairs
+// are unrecognised.
+func Logfmt(line string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	s := strings.TrimSpace(line)
+
+	for len(s) > 0 {
+		eq := strings.IndexAny(s, "= \t")
+		if eq <= 0 || s[eq] != '=' {
+			return nil, ErrUnrecognised
+		}
+
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value interface{}
+
+		if strings.HasPrefix(s, `"`) {
+			end := closingQuote(s)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated value for %s: %w", key, ErrUnrecognised)
+			}
+
+			str, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %w", key, ErrUnrecognised)
+			}
+
+			value = str
+			s = s[end+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end == -1 {
+				end = len(s)
+			}
+
+			value = convertBareValue(s[:end])
+			s = s[end:]
+		}
+
+		result[key] = value
+		s = strings.TrimLeft(s, " \t")
+	}
+
+	if len(result) == 0 {
+		return nil, ErrUnrecognised
+	}
+
+	return result, nil
+}
+
+// closingQuote returns the index of the quote ending the quoted string at the start of s.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func convertBareValue(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+
+	// ParseFloat also accepts words like "inf" and "nan", which are more likely meant as text.
+	if value != "" && strings.ContainsAny(value[:1], "0123456789+-.") && !identifierLike(value) {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+// maxExactInteger is the largest integer a float64 holds exactly.
+const maxExactInteger = 1 << 53
+
+// identifierLike reports whether a number is more likely an identifier, such as a zip code or an
+// order ID, which would be changed by converting it to a float64. Those have leading zeros, or are
+// integers too large to be held exactly.
+func identifierLike(value string) bool {
+	digits := strings.TrimLeft(value, "+-")
+
+	if len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+		return true
+	}
+
+	if strings.ContainsAny(digits, ".eE") {
+		return false
+	}
+
+	n, err := strconv.ParseUint(digits, 10, 64)
+
+	return err != nil || n > maxExactInteger
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLogfmt(t *testing.T) {
+	tests := []struct {
+		line string
+		want map[string]interface{}
+	}{
+		{
+			line: `level=info msg="request done" request_id=abc`,
+			want: map[string]interface{}{"level": "info", "msg": "request done", "request_id": "abc"},
+		},
+		{
+			line: "  a=1\tb=2  \n",
+			want: map[string]interface{}{"a": 1.0, "b": 2.0},
+		},
+		{
+			line: `msg="say \"hi\"\tthen\\leave"`,
+			want: map[string]interface{}{"msg": "say \"hi\"\tthen\\leave"},
+		},
+		{
+			line: `empty= quoted=""`,
+			want: map[string]interface{}{"empty": "", "quoted": ""},
+		},
+		{
+			line: `ok=true failed=false name=truthy`,
+			want: map[string]interface{}{"ok": true, "failed": false, "name": "truthy"},
+		},
+		{
+			line: `dur=-1.5 big=1e3 pos=+5 zero=0 half=0.5`,
+			want: map[string]interface{}{"dur": -1.5, "big": 1000.0, "pos": 5.0, "zero": 0.0, "half": 0.5},
+		},
+		{
+			// Quoted numbers stay strings, as they would in JSON.
+			line: `status="200" words=inf`,
+			want: map[string]interface{}{"status": "200", "words": "inf"},
+		},
+		{
+			line: `url=/api?x=1 key=a=b`,
+			want: map[string]interface{}{"url": "/api?x=1", "key": "a=b"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Logfmt(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q = %#v, want %#v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestLogfmtUnrecognised(t *testing.T) {
+	lines := []string{
+		"",
+		"   ",
+		"just some text",
+		// Bare keys would make any text look like logfmt, so every pair needs a value.
+		"debug a=1",
+		"a=1 debug",
+		"=value",
+		"a =1",
+		`msg="unterminated`,
+		`msg="bad \q escape"`,
+	}
+
+	for _, line := range lines {
+		if _, err := Logfmt(line); !errors.Is(err, ErrUnrecognised) {
+			t.Errorf("%q: error = %v, want it unrecognised", line, err)
+		}
+	}
+}
+
+func TestConvertBareValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{value: "0", want: 0.0},
+		{value: "-0", want: 0.0},
+		{value: "42", want: 42.0},
+		{value: "-3.5", want: -3.5},
+		{value: ".5", want: 0.5},
+		{value: "0.25", want: 0.25},
+		{value: "1e3", want: 1000.0},
+		{value: "true", want: true},
+		{value: "True", want: "True"},
+		{value: "nan", want: "nan"},
+		{value: "", want: ""},
+
+		// Leading zeros and integers too large for a float64 are more likely IDs, so are kept as text.
+		{value: "007", want: "007"},
+		{value: "-01", want: "-01"},
+		{value: "02134", want: "02134"},
+		{value: "9007199254740992", want: 9007199254740992.0},
+		{value: "9007199254740993", want: "9007199254740993"},
+		{value: "12345678901234567890", want: "12345678901234567890"},
+		{value: "0x10", want: "0x10"},
+		{value: "1.50", want: 1.5},
+	}
+
+	for _, test := range tests {
+		if got := convertBareValue(test.value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q = %#v, want %#v", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUnrecognised is returned when a line isn't in a parser's format at all, as opposed to being
+// in the right format but malformed.
+var ErrUnrecognised = errors.New("line not recognised")
+
+// Func parses a line of text into a record, which templates are then applied to.
+type Func func(line string) (map[string]interface{}, error)
+
+var types = map[string]Func{
+	"auto":   Auto,
+	"json":   JSON,
+	"logfmt": Logfmt,
+	"syslog": Syslog,
+}
+
+// RegexType is the type of parser built from a pattern with named captures.
+const RegexType = "regex"
+
+// New returns the parser for the given type. The pattern is required for the regex type, and
+// not allowed for any other.
+func New(typ string, pattern *regexp.Regexp) (Func, error) {
+	if typ == RegexType {
+		if pattern == nil {
+			return nil, fmt.Errorf("a pattern is required for the %s type", RegexType)
+		}
+
+		return Regex(pattern)
+	}
+
+	fn, ok := types[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q, must be one of %s", typ, strings.Join(Types(), ", "))
+	}
+
+	if pattern != nil {
+		return nil, fmt.Errorf("a pattern can only be used with the %s type", RegexType)
+	}
+
+	return fn, nil
+}
+
+// Types returns the names of all the parsers, for use in error messages.
+func Types() []string {
+	result := []string{RegexType}
+	for name := range types {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Auto sniffs the format of the line, trying JSON, then logfmt, then syslog. If none match, the
+// JSON error is returned, so lines which look like JSON but are broken can still be reported.
+func Auto(line string) (map[string]interface{}, error) {
+	res, jsonErr := JSON(line)
+	if jsonErr == nil {
+		return res, nil
+	}
+
+	if res, err := Logfmt(line); err == nil {
+		return res, nil
+	}
+
+	if res, err := Syslog(line); err == nil {
+		return res, nil
+	}
+
+	return nil, jsonErr
+}
+
+// Captures returns the named capture groups of a match as fields. Unnamed and unmatched groups are
+// skipped, and numbers and booleans are converted the same way as unquoted logfmt values.
+func Captures(re *regexp.Regexp, text string, loc []int) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	for i, name := range re.SubexpNames() {
+		if name == "" || loc[2*i] < 0 {
+			continue
+		}
+
+		result[name] = convertBareValue(text[loc[2*i]:loc[2*i+1]])
+	}
+
+	return result
+}
+
+// Regex returns a parser which turns the named captures of pattern into fields. Lines which
+// don't match are unrecognised.
+func Regex(pattern *regexp.Regexp) (Func, error) {
+	named := false
+	for _, name := range pattern.SubexpNames() {
+		if name != "" {
+			named = true
+			break
+		}
+	}
+
+	if !named {
+		return nil, fmt.Errorf("pattern %q has no named captures, use (?P<name>...)", pattern.String())
+	}
+
+	return func(line string) (map[string]interface{}, error) {
+		line = strings.TrimRight(line, "\r\n")
+
+		loc := pattern.FindStringSubmatchIndex(line)
+		if loc == nil {
+			return nil, ErrUnrecognised
+		}
+
+		return Captures(pattern, line, loc), nil
+	}, nil
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	got, err := JSON(`2022-06-01 app: {"msg":"hi","n":1,"nested":{"ok":true}}` + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"msg": "hi", "n": 1.0, "nested": map[string]interface{}{"ok": true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON = %#v, want %#v", got, want)
+	}
+
+	if _, err := JSON("no braces"); !errors.Is(err, ErrUnrecognised) {
+		t.Errorf("line without a brace: error = %v, want it unrecognised", err)
+	}
+
+	if _, err := JSON(`{"msg": "broken`); err == nil || errors.Is(err, ErrUnrecognised) {
+		t.Errorf("broken JSON: error = %v, want a parse error", err)
+	}
+}
+
+func TestAuto(t *testing.T) {
+	tests := []struct {
+		line  string
+		field string
+		want  interface{}
+	}{
+		{line: `{"msg":"json"}`, field: "msg", want: "json"},
+		{line: `msg=logfmt level=info`, field: "msg", want: "logfmt"},
+		{line: "<14>Jun  1 09:05:03 host app: syslog", field: "msg", want: "syslog"},
+		// JSON is tried first, so a logfmt value containing a brace is still logfmt.
+		{line: `msg=open{ level=info`, field: "msg", want: "open{"},
+	}
+
+	for _, test := range tests {
+		got, err := Auto(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if got[test.field] != test.want {
+			t.Errorf("%q: %s = %#v, want %#v", test.line, test.field, got[test.field], test.want)
+		}
+	}
+
+	if _, err := Auto("plain text"); !errors.Is(err, ErrUnrecognised) {
+		t.Errorf("plain text: error = %v, want it unrecognised", err)
+	}
+
+	// Broken JSON reports the JSON error, rather than being unrecognised.
+	if _, err := Auto(`{"msg": "broken`); err == nil || errors.Is(err, ErrUnrecognised) {
+		t.Errorf("broken JSON: error = %v, want the JSON error", err)
+	}
+}
+
+func TestRegex(t *testing.T) {
+	parse, err := Regex(regexp.MustCompile(`^(?P<time>\S+) \[(?P<level>\w+)\] (?:(?P<code>\d+) )?(?P<msg>.*)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want map[string]interface{}
+	}{
+		{
+			line: "2022-06-01T10:00:00Z [INFO] 404 not found\r\n",
+			want: map[string]interface{}{"time": "2022-06-01T10:00:00Z", "level": "INFO", "code": 404.0, "msg": "not found"},
+		},
+		{
+			// Groups which didn't take part in the match are left out.
+			line: "2022-06-01T10:00:00Z [WARN] slow",
+			want: map[string]interface{}{"time": "2022-06-01T10:00:00Z", "level": "WARN", "msg": "slow"},
+		},
+		{
+			line: "2022-06-01T10:00:00Z [INFO] 007 agent",
+			want: map[string]interface{}{"time": "2022-06-01T10:00:00Z", "level": "INFO", "code": "007", "msg": "agent"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := parse(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q = %#v, want %#v", test.line, got, test.want)
+		}
+	}
+
+	if _, err := parse("no match"); !errors.Is(err, ErrUnrecognised) {
+		t.Errorf("line which doesn't match: error = %v, want it unrecognised", err)
+	}
+
+	if _, err := Regex(regexp.MustCompile(`^(\w+) (.*)$`)); err == nil {
+		t.Error("pattern without named captures was accepted")
+	}
+}
+
+func TestNew(t *testing.T) {
+	pattern := regexp.MustCompile(`(?P<msg>.*)`)
+
+	tests := []struct {
+		typ     string
+		pattern *regexp.Regexp
+		err     string
+	}{
+		{typ: "json"},
+		{typ: "logfmt"},
+		{typ: "syslog"},
+		{typ: "auto"},
+		{typ: RegexType, pattern: pattern},
+		{typ: RegexType, err: "a pattern is required"},
+		{typ: "json", pattern: pattern, err: "a pattern can only be used"},
+		{typ: "xml", err: `unknown type "xml", must be one of auto, json, logfmt, regex, syslog`},
+	}
+
+	for _, test := range tests {
+		fn, err := New(test.typ, test.pattern)
+
+		if test.err == "" {
+			if err != nil || fn == nil {
+				t.Errorf("%s: %v", test.typ, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%s: error = %v, want %s", test.typ, err, test.err)
+		}
+	}
+}
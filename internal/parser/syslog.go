@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	rfc5424Pattern = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+)(?: (.*))?$`)
+	rfc3164Pattern = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?(.*)$`)
+)
+
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// severityLevels maps syslog severities onto the levels Dollop colours and tallies.
+var severityLevels = []string{"fatal", "fatal", "fatal", "error", "warning", "info", "info", "debug"}
+
+// Syslog parses RFC 5424 and RFC 3164 syslog lines, including the RFC 3164 form without a priority
+// which is written to files like /var/log/syslog. Fields are named so the default config works:
+// msg, time and level, along with host, app, pid, msgid, facility and severity.
+func Syslog(line string) (map[string]interface{}, error) {
+	line = strings.TrimRight(line, "\r\n")
+
+	if m := rfc5424Pattern.FindStringSubmatch(line); m != nil {
+		res := map[string]interface{}{}
+		addPriority(res, m[1])
+		addField(res, "time", m[2])
+		addField(res, "host", m[3])
+		addField(res, "app", m[4])
+		addField(res, "pid", m[5])
+		addField(res, "msgid", m[6])
+		addField(res, "structured_data", m[7])
+		res["msg"] = strings.TrimPrefix(m[8], "\ufeff")
+
+		return res, nil
+	}
+
+	if m := rfc3164Pattern.FindStringSubmatch(line); m != nil {
+		res := map[string]interface{}{}
+		addPriority(res, m[1])
+		addField(res, "time", stampToRFC3339(m[2]))
+		addField(res, "host", m[3])
+		addField(res, "app", m[4])
+		addField(res, "pid", m[5])
+		res["msg"] = m[6]
+
+		return res, nil
+	}
+
+	return nil, ErrUnrecognised
+}
+
+// addField sets the field unless the value is empty or syslog's nil value.
+func addField(res map[string]interface{}, name string, value string) {
+	if value != "" && value != "-" {
+		res[name] = value
+	}
+}
+
+func addPriority(res map[string]interface{}, priority string) {
+	pri, err := strconv.Atoi(priority)
+	if err != nil || pri > 191 {
+		return
+	}
+
+	severity := pri % 8
+	res["facility"] = facilityNames[pri/8]
+	res["severity"] = severityNames[severity]
+	res["level"] = severityLevels[severity]
+}
+
+// stampToRFC3339 converts an RFC 3164 timestamp, which has no year, into RFC 3339 assuming the current year.
+func stampToRFC3339(stamp string) string {
+	t, err := time.ParseInLocation(time.Stamp, stamp, time.Local)
+	if err != nil {
+		return stamp
+	}
+
+	return t.AddDate(time.Now().Year(), 0, 0).Format(time.RFC3339)
+}
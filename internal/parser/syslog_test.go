@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSyslog(t *testing.T) {
+	// RFC 3164 timestamps have no year, so the current one is assumed.
+	stamp := time.Date(time.Now().Year(), time.June, 1, 9, 5, 3, 0, time.Local).Format(time.RFC3339)
+
+	tests := []struct {
+		line string
+		want map[string]interface{}
+	}{
+		{
+			line: `<165>1 2022-06-01T10:00:00.003Z web01 api 8710 ID47 [exampleSDID@32473 iut="3"] started`,
+			want: map[string]interface{}{
+				"facility": "local4", "severity": "notice", "level": "info",
+				"time": "2022-06-01T10:00:00.003Z", "host": "web01", "app": "api", "pid": "8710", "msgid": "ID47",
+				"structured_data": `[exampleSDID@32473 iut="3"]`, "msg": "started",
+			},
+		},
+		{
+			// Nil values are left out, and a BOM before the message is dropped.
+			line: "<11>1 2022-06-01T10:00:00Z - app - - - \ufefffailed\n",
+			want: map[string]interface{}{
+				"facility": "user", "severity": "err", "level": "error",
+				"time": "2022-06-01T10:00:00Z", "app": "app", "msg": "failed",
+			},
+		},
+		{
+			line: `<0>1 - - - - - [a x="\]"][b]`,
+			want: map[string]interface{}{
+				"facility": "kern", "severity": "emerg", "level": "fatal",
+				"structured_data": `[a x="\]"][b]`, "msg": "",
+			},
+		},
+		{
+			line: "<34>Jun  1 09:05:03 mymachine su[230]: 'su root' failed",
+			want: map[string]interface{}{
+				"facility": "auth", "severity": "crit", "level": "fatal",
+				"time": stamp, "host": "mymachine", "app": "su", "pid": "230", "msg": "'su root' failed",
+			},
+		},
+		{
+			// As written to /var/log/syslog, without a priority.
+			line: "Jun  1 09:05:03 host cron: job done",
+			want: map[string]interface{}{"time": stamp, "host": "host", "app": "cron", "msg": "job done"},
+		},
+		{
+			// Priorities over 191 aren't valid, so are ignored.
+			line: "<192>Jun  1 09:05:03 host app:message",
+			want: map[string]interface{}{"time": stamp, "host": "host", "app": "app", "msg": "message"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Syslog(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q = %#v, want %#v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestSyslogUnrecognised(t *testing.T) {
+	lines := []string{
+		"",
+		"just some text",
+		`{"msg":"json"}`,
+		"<34>2 2022-06-01T10:00:00Z host app - - - wrong version",
+		"<34>1 2022-06-01T10:00:00Z host app - - missing structured data",
+		"jun  1 09:05:03 host app: lowercase month",
+		"Jun  1 09:05 host app: no seconds",
+	}
+
+	for _, line := range lines {
+		if _, err := Syslog(line); !errors.Is(err, ErrUnrecognised) {
+			t.Errorf("%q: error = %v, want it unrecognised", line, err)
+		}
+	}
+}
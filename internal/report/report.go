@@ -0,0 +1,161 @@
+// Package report writes captured logs as a single, self contained HTML page.
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+//go:embed report.html
+var pageSource string
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"levelClass": levelClass,
+	"upper":      strings.ToUpper,
+	"fields":     fields,
+	"isObject":   isObject,
+	"isArray":    isArray,
+	"scalar":     scalar,
+	"summarise":  summarise,
+	"timestamp":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05.000") },
+}).Parse(pageSource))
+
+// tallyLevels are the levels counted against each group, as in the UI's groups list.
+var tallyLevels = []string{"trace", "debug", "info", "warning", "error", "fatal"}
+
+type pageData struct {
+	Title     string
+	Generated time.Time
+	Groups    []groupData
+	Lines     int
+}
+
+type groupData struct {
+	ID     string
+	Group  *dollop.Group
+	Counts []levelCount
+}
+
+type levelCount struct {
+	Level string
+	Count int
+}
+
+// Write writes a page showing the groups in order, each with its lines and their metadata.
+func Write(w io.Writer, title string, groups []*dollop.Group) error {
+	data := pageData{Title: title, Generated: time.Now()}
+
+	for i, g := range groups {
+		if len(g.Lines) == 0 {
+			continue
+		}
+
+		tally := g.TallyLevels()
+		counts := []levelCount{}
+
+		for _, level := range tallyLevels {
+			if tally[level] > 0 {
+				counts = append(counts, levelCount{Level: level, Count: tally[level]})
+			}
+		}
+
+		data.Groups = append(data.Groups, groupData{ID: fmt.Sprintf("group-%d", i), Group: g, Counts: counts})
+		data.Lines += len(g.Lines)
+	}
+
+	return page.Execute(w, data)
+}
+
+// levelClass picks the CSS class colouring a level, matching on its first four letters like the UI.
+func levelClass(level string) string {
+	level = strings.ToLower(level)
+	if len(level) > 4 {
+		level = level[0:4]
+	}
+
+	switch level {
+	case "erro", "fata", "warn", "info":
+		return "level-" + level
+	}
+
+	return "level-default"
+}
+
+type field struct {
+	Key   string
+	Value interface{}
+}
+
+// fields returns an object's fields sorted by key, or an array's items keyed by index.
+func fields(value interface{}) []field {
+	result := []field{}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			result = append(result, field{Key: k, Value: v[k]})
+		}
+
+	case []interface{}:
+		for i, item := range v {
+			result = append(result, field{Key: fmt.Sprintf("[%d]", i), Value: item})
+		}
+	}
+
+	return result
+}
+
+// summarise describes the size of an object or array.
+func summarise(value interface{}) string {
+	count := len(fields(value))
+
+	noun := "items"
+	if isObject(value) {
+		noun = "fields"
+	}
+
+	if count == 1 {
+		noun = strings.TrimSuffix(noun, "s")
+	}
+
+	return fmt.Sprintf("%d %s", count, noun)
+}
+
+func isObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+func isArray(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+// scalar formats a value which isn't an object or array, showing whole numbers without a decimal point.
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
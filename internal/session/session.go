@@ -0,0 +1,216 @@
+// Package session records the input to a file as it's read, along with what was selected in the UI,
+// so it can be reopened later.
+//
+// A session file is NDJSON, appended to as lines arrive. Each entry is either a line of input with
+// the time it was read, or the UI's state when it closed. Reopening a session parses the lines
+// again, then restores the latest state.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elseano/dollop/internal/input"
+)
+
+const (
+	lineEntry  = "line"
+	stateEntry = "state"
+)
+
+const readerSize = 1048576
+
+// State is what was selected in the UI. Groups are identified by their value.
+type State struct {
+	Group     string     `json:"group"`
+	Line      int        `json:"line"`
+	Bookmarks []Bookmark `json:"bookmarks,omitempty"`
+}
+
+// Bookmark marks a line within a group.
+type Bookmark struct {
+	Group string `json:"group"`
+	Line  int    `json:"line"`
+}
+
+type entry struct {
+	Type   string    `json:"type"`
+	At     time.Time `json:"at"`
+	Source string    `json:"source,omitempty"`
+	Text   string    `json:"text,omitempty"`
+	State  *State    `json:"state,omitempty"`
+}
+
+// Recorder appends entries to a session file.
+type Recorder struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// Create opens a session file for recording, appending to it if it already exists.
+func Create(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+
+	return &Recorder{file: file, encoder: encoder}, nil
+}
+
+func (r *Recorder) write(e entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.encoder.Encode(e)
+}
+
+// SaveState records what's selected in the UI, replacing any earlier state when the session is opened.
+func (r *Recorder) SaveState(state State) error {
+	return r.write(entry{Type: stateEntry, At: time.Now(), State: &state})
+}
+
+// Close closes the session file.
+func (r *Recorder) Close() error {
+	return r.file.Close()
+}
+
+// Record wraps a Reader, recording each line to the session as it's read. Restarting is passed on
+// when the reader supports it.
+func Record(reader input.Reader, recorder *Recorder) input.Reader {
+	recording := &recordingReader{Reader: reader, recorder: recorder}
+
+	if restarter, ok := reader.(input.Restarter); ok {
+		return &restartableRecordingReader{recordingReader: recording, restarter: restarter}
+	}
+
+	return recording
+}
+
+type recordingReader struct {
+	input.Reader
+	recorder *Recorder
+}
+
+func (r *recordingReader) ReadLine() (input.Line, error) {
+	line, err := r.Reader.ReadLine()
+	if err != nil {
+		return line, err
+	}
+
+	line.Time = line.ReadTime()
+
+	if err := r.recorder.write(entry{Type: lineEntry, At: line.Time, Source: line.Source, Text: line.Text}); err != nil {
+		return line, fmt.Errorf("recording session: %w", err)
+	}
+
+	return line, nil
+}
+
+type restartableRecordingReader struct {
+	*recordingReader
+	restarter input.Restarter
+}
+
+func (r *restartableRecordingReader) Restart() error {
+	return r.restarter.Restart()
+}
+
+// Reader reads the lines recorded in a session, with the times they were originally read.
+type Reader struct {
+	file   *os.File
+	reader *bufio.Reader
+	state  *State
+	lineNo int
+}
+
+// Open opens a session file for reading. The latest saved state is available straight away.
+func Open(path string) (*Reader, error) {
+	state, err := latestState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{file: file, reader: bufio.NewReaderSize(file, readerSize), state: state}, nil
+}
+
+// latestState scans the session for the last state saved, or nil if there isn't one.
+func latestState(path string) (*State, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var state *State
+
+	r := &Reader{file: file, reader: bufio.NewReaderSize(file, readerSize)}
+
+	for {
+		e, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return state, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if e.Type == stateEntry {
+			state = e.State
+		}
+	}
+}
+
+// State returns the latest state saved in the session, or nil if there isn't one.
+func (r *Reader) State() *State {
+	return r.state
+}
+
+// ReadLine returns the next recorded line, or io.EOF at the end of the session.
+func (r *Reader) ReadLine() (input.Line, error) {
+	for {
+		e, err := r.next()
+		if err != nil {
+			return input.Line{}, err
+		}
+
+		if e.Type == lineEntry {
+			return input.Line{Source: e.Source, Text: e.Text, Time: e.At}, nil
+		}
+	}
+}
+
+func (r *Reader) next() (entry, error) {
+	text, err := r.reader.ReadBytes('\n')
+	if err != nil {
+		// A session which was still being written may end part way through an entry.
+		return entry{}, err
+	}
+
+	r.lineNo++
+
+	var e entry
+	if err := json.Unmarshal(text, &e); err != nil {
+		return entry{}, fmt.Errorf("%s line %d: %w", r.file.Name(), r.lineNo, err)
+	}
+
+	return e, nil
+}
+
+// Close closes the session file.
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -30,12 +30,34 @@ var templateFuncs = template.FuncMap{
 	"TruncateLeft":  truncateLeft,
 	"Truncate":      truncateRight,
 	"FormatSeconds": formatSeconds,
+	"Field":         field,
 	"div":           div,
 	"mul":           mul,
 	"add":           add,
 	"sub":           sub,
 }
 
+// field looks up a field by name, then by its dotted path through nested values. Some loggers, such
+// as those writing Elastic Common Schema, use flat keys like "log.level" which a template's
+// .log.level can't reach.
+func field(data interface{}, name string) interface{} {
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if value, ok := values[name]; ok {
+		return value
+	}
+
+	head, rest, found := strings.Cut(name, ".")
+	if !found {
+		return nil
+	}
+
+	return field(values[head], rest)
+}
+
 func titleCase(str string) string {
 	return cases.Title(language.English).String(str)
 }
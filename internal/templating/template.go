@@ -2,27 +2,29 @@ package templating
 
 import (
 	"bytes"
-	"log"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
-func BuildTemplateText(value string) *template.Template {
+// ParseTemplateText parses a template, returning an error if it's invalid.
+func ParseTemplateText(value string) (*template.Template, error) {
 	tmpl, err := template.New("tmpl").Funcs(templateFuncs).Parse(value)
 	if err != nil {
-		log.Fatalf("Cannot build template for \"%s\": %s", value, err.Error())
-		panic("Invalid config")
+		return nil, err
 	}
 
-	return tmpl.Option("missingkey=invalid")
+	return tmpl.Option("missingkey=invalid"), nil
 }
 
-func BuildTemplate(value string) *template.Template {
+// ParseTemplate parses a field name, or a template when the value contains "{{". Field names are
+// looked up with Field, so dots reach nested values as well as keys which contain dots.
+func ParseTemplate(value string) (*template.Template, error) {
 	if strings.Contains(value, "{{") {
-		return BuildTemplateText(value)
+		return ParseTemplateText(value)
 	}
 
-	return BuildTemplateText("{{ ." + value + " }}")
+	return ParseTemplateText("{{ Field . " + strconv.Quote(value) + " }}")
 }
 
 func ApplyTemplate(tmpl *template.Template, data interface{}) (string, error) {
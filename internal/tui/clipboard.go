@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+type copiedMsg struct {
+	what string
+	// osc52 is set when there's no system clipboard, so the text must be copied by the terminal.
+	osc52 bool
+	text  string
+}
+
+// copyToClipboard copies the text to the system clipboard. Without one, such as over SSH, the
+// terminal is asked to copy it using an OSC52 escape sequence, which Update writes.
+func copyToClipboard(text string, what string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err != nil {
+			return copiedMsg{what: what, osc52: true, text: text}
+		}
+
+		return copiedMsg{what: what}
+	}
+}
+
+// writeOSC52 asks the terminal to copy the text. It must only be called from Update, on the
+// program's goroutine rather than a command's, and writes the sequence in one go so it lands
+// between the renderer's frames rather than splitting one.
+func writeOSC52(text string) {
+	sequence := fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+
+	// tmux only passes the sequence on to the terminal when it's wrapped.
+	if os.Getenv("TMUX") != "" {
+		sequence = fmt.Sprintf("\x1bPtmux;\x1b%s\x1b\\", sequence)
+	}
+
+	os.Stdout.WriteString(sequence)
+}
+
+// copyField copies the value of the field under the cursor in the detail view. Objects and arrays
+// are copied as JSON.
+func (m *Model) copyField() tea.Cmd {
+	node := m.tree.current()
+	if node == nil {
+		m.SetStatus("No field selected")
+		return nil
+	}
+
+	value := formatValue(node.value)
+
+	if node.isContainer() {
+		raw, err := json.Marshal(node.value)
+		if err != nil {
+			m.SetStatus(fmt.Sprintf("Copy failed: %s", err.Error()))
+			return nil
+		}
+
+		value = string(raw)
+	}
+
+	return copyToClipboard(value, node.key)
+}
+
+// copyLine copies the text the line was parsed from, or the record for lines which were added
+// already parsed.
+func (m *Model) copyLine() tea.Cmd {
+	text := m.focusLog.Raw
+
+	if m.focusLog.IsRecord() {
+		raw, err := json.Marshal(m.focusLog.Record())
+		if err != nil {
+			m.SetStatus(fmt.Sprintf("Copy failed: %s", err.Error()))
+			return nil
+		}
+
+		text = string(raw)
+	}
+
+	return copyToClipboard(text, "line")
+}
+
+// copyGroup copies every line in the selected group as NDJSON.
+func (m *Model) copyGroup() tea.Cmd {
+	group, ok := m.list.SelectedItem().(*logGroup)
+	if !ok {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := dollop.WriteNDJSON(&buf, group.group.Lines); err != nil {
+		m.SetStatus(fmt.Sprintf("Copy failed: %s", err.Error()))
+		return nil
+	}
+
+	return copyToClipboard(buf.String(), fmt.Sprintf("%d lines from %s", len(group.group.Lines), group.group.Title))
+}
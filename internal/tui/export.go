@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+// Export scopes.
+const (
+	exportGroup = "group"
+	exportAll   = "all"
+)
+
+func newExportInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "file.ndjson, .json, .csv or .txt, then any csv columns, like: out.csv time,level,msg"
+
+	return input
+}
+
+// startExport opens the prompt for the file to export to.
+func (m *Model) startExport(scope string) tea.Cmd {
+	m.exporting = scope
+	m.exportErr = ""
+
+	switch {
+	case scope == exportGroup:
+		m.exportInput.Prompt = "Export group to: "
+	case m.query != nil:
+		m.exportInput.Prompt = "Export search results to: "
+	default:
+		m.exportInput.Prompt = "Export everything to: "
+	}
+
+	m.exportInput.SetValue("")
+	m.exportInput.Width = m.width - len(m.exportInput.Prompt) - 1
+
+	return m.exportInput.Focus()
+}
+
+// updateExport handles keys while the export prompt is open.
+func (m *Model) updateExport(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keyMap.Quit):
+		return tea.Quit
+
+	case msg.Type == tea.KeyEnter:
+		if err := m.export(m.exportInput.Value()); err != nil {
+			m.exportErr = err.Error()
+			return nil
+		}
+
+		m.exporting = ""
+		m.exportInput.Blur()
+
+	case msg.Type == tea.KeyEsc:
+		m.exporting = ""
+		m.exportInput.Blur()
+
+	default:
+		m.exportErr = ""
+
+		var cmd tea.Cmd
+		m.exportInput, cmd = m.exportInput.Update(msg)
+		return cmd
+	}
+
+	return nil
+}
+
+// export writes the groups in scope to the file, in the format its extension implies. Anything after
+// the file name is the list of CSV columns.
+func (m *Model) export(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return fmt.Errorf("no file given")
+	}
+
+	path := fields[0]
+	opts := dollop.ExportOptions{Format: dollop.ExportFormatFor(path), Query: m.query}
+
+	if len(fields) > 1 {
+		opts.Columns = strings.Split(strings.Join(fields[1:], ""), ",")
+	}
+
+	groups := []*dollop.Group{}
+	description := "everything"
+
+	if m.exporting == exportGroup {
+		group, ok := m.list.SelectedItem().(*logGroup)
+		if !ok {
+			return fmt.Errorf("no group selected")
+		}
+
+		groups = append(groups, group.group)
+		description = group.group.Title
+	} else {
+		// The list only has the groups matching the search, when there is one.
+		for _, item := range m.list.Items() {
+			groups = append(groups, item.(*logGroup).group)
+		}
+
+		if m.query != nil {
+			description = "search results"
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := dollop.Export(file, groups, opts); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	m.SetStatus(fmt.Sprintf("Exported %s to %s", description, path))
+
+	return nil
+}
@@ -3,5 +3,5 @@ package tui
 import tea "github.com/charmbracelet/bubbletea"
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.scanLogs())
+	return m.startPipeline()
 }
@@ -3,28 +3,30 @@ package tui
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/elseano/dollop/pkg/dollop"
 )
 
+// logGroup shows a group in the groups list, remembering which of its lines was last selected.
 type logGroup struct {
-	title        string
-	description  string
-	groupValue   string
-	timestamp    time.Time
-	lines        []logLine
+	group        *dollop.Group
 	selectedLine int
 }
 
 var faintColor = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#aaaaaa", Dark: "#333333"})
 
-func (i logGroup) Title() string { return i.title }
+func (i logGroup) Title() string { return i.group.Title }
 func (i logGroup) Description() string {
 	b := strings.Builder{}
-	tally := i.TallyLevels()
+	tally := i.group.TallyLevels()
+
+	b.WriteString(i.group.Description)
+
+	if len(i.group.Sources) > 0 {
+		b.WriteString(faintColor.Render(" " + strings.Join(i.group.Sources, ", ")))
+	}
 
-	b.WriteString(i.description)
 	b.WriteString(" ")
 
 	logCounts := strings.Builder{}
@@ -46,15 +48,3 @@ func (i logGroup) Description() string {
 	return b.String()
 }
 func (i logGroup) FilterValue() string { return "" }
-
-func (i logGroup) TallyLevels() map[string]int {
-	result := map[string]int{}
-
-	for _, line := range i.lines {
-		cv := result[line.level]
-		cv++
-		result[line.level] = cv
-	}
-
-	return result
-}
@@ -12,7 +12,32 @@ type KeyMap struct {
 
 	Select key.Binding
 	Escape key.Binding
-	Quit   key.Binding
+
+	Expand   key.Binding
+	Collapse key.Binding
+	Raw      key.Binding
+
+	CopyField key.Binding
+	CopyLine  key.Binding
+	CopyGroup key.Binding
+
+	ReplayPause key.Binding
+	Step        key.Binding
+	SeekBack    key.Binding
+	SeekForward key.Binding
+	Faster      key.Binding
+	Slower      key.Binding
+
+	Bookmark     key.Binding
+	NextBookmark key.Binding
+
+	Search    key.Binding
+	Export    key.Binding
+	ExportAll key.Binding
+	Follow    key.Binding
+	Pause     key.Binding
+	Restart   key.Binding
+	Quit      key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -51,6 +76,89 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("←/esc", "back"),
 		),
 
+		Expand: key.NewBinding(
+			key.WithKeys("right", "enter"),
+			key.WithHelp("→", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left"),
+			key.WithHelp("←", "collapse"),
+		),
+		Raw: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "raw json"),
+		),
+		CopyField: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy field"),
+		),
+		CopyLine: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy line"),
+		),
+		CopyGroup: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy group"),
+		),
+
+		ReplayPause: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "pause replay"),
+		),
+		Step: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "step"),
+		),
+		SeekBack: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "back 10s"),
+		),
+		SeekForward: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "skip 10s"),
+		),
+		Faster: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "faster"),
+		),
+		Slower: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "slower"),
+		),
+
+		Bookmark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "bookmark"),
+		),
+		NextBookmark: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp("'", "next bookmark"),
+		),
+
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export group"),
+		),
+		ExportAll: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "export all"),
+		),
+		Follow: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "follow"),
+		),
+		Pause: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause"),
+		),
+		Restart: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "restart"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c"),
 			key.WithHelp("ctrl+c", "quit"),
@@ -69,6 +177,26 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 			k.GoToStart,
 			k.Select,
 			k.Escape,
+			k.Expand,
+			k.Collapse,
+			k.Raw,
+			k.CopyField,
+			k.CopyLine,
+			k.CopyGroup,
+			k.Bookmark,
+			k.NextBookmark,
+			k.Search,
+			k.Export,
+			k.ExportAll,
+			k.Follow,
+			k.Pause,
+			k.ReplayPause,
+			k.Step,
+			k.SeekBack,
+			k.SeekForward,
+			k.Faster,
+			k.Slower,
+			k.Restart,
 			k.Quit,
 		},
 	}
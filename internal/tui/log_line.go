@@ -3,28 +3,23 @@ package tui
 import (
 	"io"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/elseano/dollop/pkg/dollop"
 )
 
-type logTag struct {
-	name  string
-	value string
-}
-
+// logLine shows a line in the logs list.
 type logLine struct {
-	level     string
-	timestamp time.Time
-	message   string
-	data      map[string]interface{}
-	tags      []logTag
+	dollop.Line
+	// index is the line's position in its group, which searches can hide lines from.
+	index      int
+	bookmarked bool
 }
 
 func (line logLine) FilterValue() string {
-	return line.message
+	return line.Message
 }
 
 type logLineDelegate struct{ isActive bool }
@@ -38,6 +33,7 @@ var selectedColor = lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"}
 var selectedBackgroundColor = lipgloss.AdaptiveColor{Light: "#f49efa", Dark: "#890792"}
 var selectedBackgroundStyle = lipgloss.NewStyle().Background(selectedBackgroundColor)
 var unselectedBackgroundStyle = lipgloss.NewStyle()
+var bookmarkStyle = lipgloss.NewStyle().Foreground(selectedColor)
 
 // func DebugColors() {
 // 	fmt.Printf("Info: %#v\n", labelStyles["info"])
@@ -50,10 +46,15 @@ func (lineDelegate logLineDelegate) Render(w io.Writer, m list.Model, index int,
 	line := item.(logLine)
 	builder := strings.Builder{}
 
+	marker := "  "
+	if line.bookmarked {
+		marker = bookmarkStyle.Render("● ")
+	}
+
 	if index == m.Index() && lineDelegate.isActive {
-		builder.WriteString("  " + line.String(true))
+		builder.WriteString(marker + line.String(true))
 	} else {
-		builder.WriteString("  " + line.String(false))
+		builder.WriteString(marker + line.String(false))
 	}
 
 	w.Write([]byte(builder.String()))
@@ -66,7 +67,7 @@ func (line logLine) String(selected bool) string {
 		lineStyle = selectedBackgroundStyle
 	}
 
-	messageColour := getMessageColor(line.level)
+	messageColour := getMessageColor(line.Level)
 	textColor := normalColor
 
 	if messageColour == messageColors["default"] {
@@ -76,11 +77,11 @@ func (line logLine) String(selected bool) string {
 	messageStyle := lipgloss.NewStyle().Foreground(textColor)
 	labelStyle := lipgloss.NewStyle().Background(messageColour).Foreground(textColor)
 
-	if line.level == "debug" || line.level == "trace" {
+	if line.Level == "debug" || line.Level == "trace" {
 		labelStyle = lipgloss.NewStyle().Foreground(textColor)
 	}
 
-	levelStr := trimString(strings.ToUpper(line.level), 4)
+	levelStr := trimString(strings.ToUpper(line.Level), 4)
 	levelStr = " " + levelStr + " "
 
 	if selected {
@@ -90,24 +91,29 @@ func (line logLine) String(selected bool) string {
 	}
 
 	builder.WriteString(lineStyle.Render(strings.Repeat(" ", 9-lipgloss.Width(levelStr))))
-	builder.WriteString(lineStyle.Inherit(messageStyle).Render(strings.ReplaceAll(line.message, "\n", " ")))
+	builder.WriteString(lineStyle.Inherit(messageStyle).Render(strings.ReplaceAll(line.Message, "\n", " ")))
 
-	if len(line.tags) > 0 {
+	if len(line.Tags) > 0 {
 		builder.WriteString(lineStyle.Render("   "))
 	}
 
-	for _, t := range line.tags {
-		if t.value != "" {
+	for _, t := range line.Tags {
+		if t.Value != "" {
 			builder.WriteString(lineStyle.Render(" "))
-			builder.WriteString(lineStyle.Inherit(tagNameStyle).Render(t.name))
+			builder.WriteString(lineStyle.Inherit(tagNameStyle).Render(t.Name))
 			builder.WriteString(lineStyle.Render(" "))
-			builder.WriteString(lineStyle.Inherit(tagValueStyle).Render(t.value))
+			builder.WriteString(lineStyle.Inherit(tagValueStyle).Render(t.Value))
 		} else {
 			builder.WriteString(lineStyle.Render(" "))
-			builder.WriteString(lineStyle.Inherit(tagSoloStyle).Render(t.name))
+			builder.WriteString(lineStyle.Inherit(tagSoloStyle).Render(t.Name))
 		}
 	}
 
+	if line.TimestampFailed {
+		builder.WriteString(lineStyle.Render("    "))
+		builder.WriteString(lineStyle.Inherit(badTimeStyle).Render("bad time"))
+	}
+
 	return builder.String()
 }
 
@@ -3,8 +3,12 @@ package tui
 import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/session"
+	"github.com/elseano/dollop/pkg/dollop"
 )
 
 type Model struct {
@@ -12,22 +16,48 @@ type Model struct {
 
 	list           list.Model
 	logs           list.Model
+	groups         []list.Item
 	focus          string
 	statusLine     string
 	rightSideWidth int
 
 	focusLog *logLine
 	detail   viewport.Model
+	tree     *metadataTree
 
 	Help   help.Model
 	keyMap KeyMap
 
+	searching   bool
+	searchInput textinput.Model
+	searchErr   string
+	query       *dollop.Query
+	matches     map[*dollop.Group]int
+
+	exporting   string
+	exportInput textinput.Model
+	exportErr   string
+
+	bookmarks map[bookmark]bool
+	restore   *session.State
+
+	following bool
+	paused    bool
+	held      *scanMsg
+	newLines  int
+
 	disconnected bool
+	evicted      int
 
-	config config.Config
+	config    config.Config
+	input     input.Reader
+	processor *dollop.Processor
+	updates   chan scanMsg
+	reloads   chan configReload
+	onUpdate  func()
 }
 
-func New(config config.Config) (*Model, error) {
+func New(config config.Config, reader input.Reader) (*Model, error) {
 	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "Requests"
 	l.SetShowHelp(false)
@@ -41,12 +71,58 @@ func New(config config.Config) (*Model, error) {
 	logs.SetShowFilter(false)
 	logs.SetFilteringEnabled(false)
 
+	keyMap := DefaultKeyMap()
+	_, restartable := reader.(input.Restarter)
+	keyMap.Restart.SetEnabled(restartable)
+
+	_, replaying := reader.(input.Replayer)
+	keyMap.ReplayPause.SetEnabled(replaying)
+	keyMap.Step.SetEnabled(replaying)
+	keyMap.SeekBack.SetEnabled(replaying)
+	keyMap.SeekForward.SetEnabled(replaying)
+	keyMap.Faster.SetEnabled(replaying)
+	keyMap.Slower.SetEnabled(replaying)
+	keyMap.Expand.SetEnabled(false)
+	keyMap.Collapse.SetEnabled(false)
+	keyMap.Raw.SetEnabled(false)
+	keyMap.CopyField.SetEnabled(false)
+	keyMap.CopyLine.SetEnabled(false)
+	keyMap.CopyGroup.SetEnabled(false)
+
+	processor, err := dollop.NewProcessor(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Model{
 		list:         l,
 		logs:         logs,
 		config:       config,
+		input:        reader,
+		processor:    processor,
+		updates:      make(chan scanMsg),
+		reloads:      make(chan configReload, 1),
+		bookmarks:    map[bookmark]bool{},
+		searchInput:  newSearchInput(),
+		exportInput:  newExportInput(),
 		Help:         help.New(),
-		keyMap:       DefaultKeyMap(),
+		keyMap:       keyMap,
 		disconnected: false,
 	}, nil
 }
+
+// Processor returns the processor the Model shows, which must only be used while holding GroupsLock.
+func (m *Model) Processor() *dollop.Processor {
+	return m.processor
+}
+
+// OnUpdate sets a function to call whenever the groups change. It must be set before the program starts.
+func (m *Model) OnUpdate(fn func()) {
+	m.onUpdate = fn
+}
+
+// ReloadConfig reprocesses everything the Model has kept with a new config. When the config failed to
+// load, the error is shown in the status bar instead, and the current config is kept.
+func (m *Model) ReloadConfig(config config.Config, err error) {
+	m.reloads <- configReload{config: config, err: err}
+}
@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+const (
+	// updateInterval limits how often the UI is refreshed while lines are arriving.
+	updateInterval = 75 * time.Millisecond
+
+	// maxBatchSize is the most lines parsed before giving the UI a chance to render.
+	maxBatchSize = 1000
+)
+
+// groupsMutex guards the processor and its groups, which are written by the pipeline while the UI reads them.
+var groupsMutex = sync.Mutex{}
+
+// scanMsg is sent by the pipeline with everything that happened since the previous one.
+type scanMsg struct {
+	// groups is the new order of the groups list, or nil if it hasn't changed.
+	groups  []list.Item
+	changed map[*dollop.Group]bool
+	// trimmed is how many lines were removed from the start of each group by the retention limits.
+	trimmed map[*dollop.Group]int
+	status  string
+	// lines is how many lines were read for the update.
+	lines int
+	// evicted is the total number of groups evicted by the retention limits.
+	evicted int
+	// err is set when the input has stopped, either because it's finished or the process exited.
+	err error
+	// reloaded is set when the config changed and everything was reprocessed, or a replay was rewound,
+	// replacing every group.
+	reloaded bool
+	// rewound is set when a replay was rewound, and the lines up to where it landed are being read again.
+	rewound bool
+	// configErr is set when a changed config couldn't be loaded, and the previous one is still in use.
+	configErr error
+}
+
+// configReload is a config which was loaded after the config file changed, or the error loading it.
+type configReload struct {
+	config config.Config
+	err    error
+}
+
+type readResult struct {
+	line input.Line
+	err  error
+}
+
+// pipeline reads and parses the input in the background, keeping a list item for each group.
+type pipeline struct {
+	processor *dollop.Processor
+	items     map[*dollop.Group]*logGroup
+	// onUpdate is called whenever the groups change, for anything else showing them.
+	onUpdate func()
+}
+
+// GroupsLock returns the lock which must be held while using the processor of a running Model.
+func GroupsLock() sync.Locker {
+	return &groupsMutex
+}
+
+// startPipeline starts reading and parsing the input, sending updates to the UI.
+func (m Model) startPipeline() tea.Cmd {
+	reads := make(chan readResult, maxBatchSize)
+	p := &pipeline{processor: m.processor, items: map[*dollop.Group]*logGroup{}, onUpdate: m.onUpdate}
+
+	go readInput(m.input, reads)
+	go p.run(reads, m.reloads, m.updates)
+
+	return m.waitForUpdate()
+}
+
+func (m Model) waitForUpdate() tea.Cmd {
+	return func() tea.Msg {
+		return <-m.updates
+	}
+}
+
+func readInput(reader input.Reader, reads chan<- readResult) {
+	for {
+		line, err := reader.ReadLine()
+		reads <- readResult{line: line, err: err}
+
+		if err != nil {
+			// A process which has exited can be restarted, and a replay which has finished can be
+			// rewound until it's closed, after which there'll be more to read.
+			var exitErr *input.ExitError
+			_, replaying := reader.(input.Replayer)
+			if errors.Is(err, os.ErrClosed) || !errors.As(err, &exitErr) && !replaying {
+				return
+			}
+		}
+	}
+}
+
+// run parses lines as they're read, sending at most one update to the UI per interval.
+// Updates accumulate while the UI is busy, so parsing never waits on rendering.
+func (p *pipeline) run(reads <-chan readResult, reloads <-chan configReload, updates chan<- scanMsg) {
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	// The first update shows the initial groups, even if nothing has been read yet.
+	groupsMutex.Lock()
+	pending := &scanMsg{groups: p.groupItems(), changed: map[*dollop.Group]bool{}}
+	groupsMutex.Unlock()
+
+	ready := true
+
+	for {
+		var out chan<- scanMsg
+		var next scanMsg
+
+		if pending != nil && ready {
+			out = updates
+			next = *pending
+		}
+
+		select {
+		case res := <-reads:
+			if pending == nil {
+				pending = &scanMsg{changed: map[*dollop.Group]bool{}}
+			}
+
+			p.processBatch(res, reads, pending)
+			p.notify()
+
+		case reload := <-reloads:
+			if pending == nil {
+				pending = &scanMsg{changed: map[*dollop.Group]bool{}}
+			}
+
+			p.reload(reload, pending)
+			p.notify()
+
+		case <-ticker.C:
+			ready = true
+
+			// Groups can age out while nothing is being read.
+			if p.processor.Config().MaxAge > 0 {
+				if pending == nil {
+					pending = &scanMsg{changed: map[*dollop.Group]bool{}}
+				}
+
+				groupsMutex.Lock()
+				p.collect(pending)
+				groupsMutex.Unlock()
+
+				if len(pending.changed) == 0 && pending.groups == nil {
+					pending = nil
+				} else {
+					p.notify()
+				}
+			}
+
+		case out <- next:
+			pending = nil
+			ready = false
+		}
+	}
+}
+
+func (p *pipeline) notify() {
+	if p.onUpdate != nil {
+		p.onUpdate()
+	}
+}
+
+// processBatch parses the result along with any others already waiting, up to maxBatchSize, then
+// moves the groups which changed into their new positions.
+func (p *pipeline) processBatch(res readResult, reads <-chan readResult, pending *scanMsg) {
+	groupsMutex.Lock()
+	defer groupsMutex.Unlock()
+
+	for i := 1; ; i++ {
+		if errors.Is(res.err, input.ErrRewound) {
+			p.rewind(pending)
+		} else if res.err != nil {
+			pending.err = res.err
+			break
+		} else {
+			result := p.processor.ProcessAt(res.line.Source, res.line.Text, res.line.ReadTime())
+			pending.lines++
+			if result.Status != "" {
+				pending.status = result.Status
+			}
+		}
+
+		if i == maxBatchSize {
+			break
+		}
+
+		select {
+		case res = <-reads:
+			continue
+		default:
+		}
+
+		break
+	}
+
+	p.collect(pending)
+}
+
+// reload reprocesses everything with a new config. The groups are all replaced, so the list items
+// are rebuilt too.
+func (p *pipeline) reload(reload configReload, pending *scanMsg) {
+	if reload.err != nil {
+		pending.configErr = reload.err
+		return
+	}
+
+	groupsMutex.Lock()
+	defer groupsMutex.Unlock()
+
+	if err := p.processor.Reconfigure(reload.config); err != nil {
+		pending.configErr = err
+		return
+	}
+
+	p.items = map[*dollop.Group]*logGroup{}
+
+	p.collect(pending)
+	pending.groups = p.groupItems()
+	pending.reloaded = true
+	pending.configErr = nil
+}
+
+// rewind drops everything read so far, as a replay has been rewound and is reading it again.
+func (p *pipeline) rewind(pending *scanMsg) {
+	p.processor.Reset()
+	p.items = map[*dollop.Group]*logGroup{}
+
+	pending.groups = p.groupItems()
+	pending.reloaded = true
+	pending.rewound = true
+	pending.err = nil
+}
+
+// collect sorts the groups and applies the retention limits, recording what changed in the update.
+func (p *pipeline) collect(pending *scanMsg) {
+	orderChanged := p.processor.Reorder()
+
+	evicted := p.processor.Evict(time.Now())
+	for _, group := range evicted {
+		delete(p.items, group)
+	}
+
+	for _, group := range p.processor.Changed() {
+		pending.changed[group] = true
+	}
+
+	for group, n := range p.processor.Trimmed() {
+		if pending.trimmed == nil {
+			pending.trimmed = map[*dollop.Group]int{}
+		}
+
+		pending.trimmed[group] += n
+	}
+
+	if orderChanged || len(evicted) > 0 {
+		pending.groups = p.groupItems()
+	}
+
+	pending.evicted = p.processor.EvictedCount()
+}
+
+// groupItems returns the list items for the groups, in order.
+func (p *pipeline) groupItems() []list.Item {
+	groups := p.processor.Groups()
+	items := make([]list.Item, len(groups))
+
+	for i, g := range groups {
+		item, ok := p.items[g]
+		if !ok {
+			item = &logGroup{group: g}
+			p.items[g] = item
+		}
+
+		items[i] = item
+	}
+
+	return items
+}
@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/elseano/dollop/internal/config"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+// PrintOptions controls how Print writes the logs.
+type PrintOptions struct {
+	// FlushAfter buffers each group's lines, writing them together once the group has been quiet
+	// for this long. When it's zero, lines are written as they arrive, prefixed by their group.
+	FlushAfter time.Duration
+	// Colour keeps the styling of the logs list. Without it, plain text is written.
+	Colour bool
+}
+
+// Lines are only kept until they've been written and their group has gone quiet, so Print keeps far
+// less than the UI, whatever the config's limits.
+const (
+	printMaxGroups = 1000
+	printMaxLines  = 10000
+)
+
+// ansiSequence matches the styling lipgloss adds, which it still partly emits without colours.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+var (
+	printTimeStyle  = lipgloss.NewStyle().Foreground(dimColor)
+	printGroupStyle = lipgloss.NewStyle().Foreground(infoColor)
+	printTitleStyle = lipgloss.NewStyle().Foreground(brightColor).Bold(true)
+)
+
+// printer writes entries as plain coloured lines, for when the full screen UI can't be used.
+type printer struct {
+	w         io.Writer
+	processor *dollop.Processor
+	opts      PrintOptions
+	pending   map[*dollop.Group]*printBuffer
+	count     int
+}
+
+// printBuffer holds a group's rendered lines until it goes quiet.
+type printBuffer struct {
+	group *dollop.Group
+	lines []string
+	last  time.Time
+}
+
+// Print reads the input, writing each entry as a line coloured like the UI's logs list. It returns
+// once the input runs out, or the command it's reading from exits.
+func Print(w io.Writer, cfg config.Config, reader input.Reader, opts PrintOptions) error {
+	if cfg.MaxGroups <= 0 || cfg.MaxGroups > printMaxGroups {
+		cfg.MaxGroups = printMaxGroups
+	}
+
+	if cfg.MaxLines <= 0 || cfg.MaxLines > printMaxLines {
+		cfg.MaxLines = printMaxLines
+	}
+
+	// Groups with errors have been written like any other, so they needn't be kept either.
+	cfg.PinErrors = false
+
+	processor, err := dollop.NewProcessor(cfg)
+	if err != nil {
+		return err
+	}
+
+	p := &printer{
+		w:         w,
+		processor: processor,
+		opts:      opts,
+		pending:   map[*dollop.Group]*printBuffer{},
+	}
+
+	reads := make(chan readResult, maxBatchSize)
+	go readUntilError(reader, reads)
+
+	var tick <-chan time.Time
+	if opts.FlushAfter > 0 {
+		ticker := time.NewTicker(updateInterval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case res := <-reads:
+			if res.err != nil {
+				flushErr := p.flush(func(*printBuffer) bool { return true })
+
+				var exitErr *input.ExitError
+				if errors.Is(res.err, io.EOF) || errors.As(res.err, &exitErr) {
+					return flushErr
+				}
+
+				return res.err
+			}
+
+			if err := p.process(res.line); err != nil {
+				return err
+			}
+
+		case now := <-tick:
+			err := p.flush(func(b *printBuffer) bool { return now.Sub(b.last) >= opts.FlushAfter })
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readUntilError(reader input.Reader, reads chan<- readResult) {
+	for {
+		line, err := reader.ReadLine()
+		reads <- readResult{line: line, err: err}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *printer) process(line input.Line) error {
+	result := p.processor.ProcessAt(line.Source, line.Text, line.ReadTime())
+	group := result.Group
+
+	var text string
+	if result.Continuation {
+		text = printTimeStyle.Render("    " + strings.TrimRight(line.Text, "\r\n"))
+	} else {
+		text = p.render(group, group.Lines[len(group.Lines)-1])
+	}
+
+	if err := p.write(group, text); err != nil {
+		return err
+	}
+
+	// Everything is kept by the processor until the retention limits are applied. Evicted groups
+	// which are still buffered are written straight away.
+	p.count++
+	if p.count%maxBatchSize == 0 {
+		p.processor.Reorder()
+		p.processor.Changed()
+
+		for _, evicted := range p.processor.Evict(time.Now()) {
+			if b, ok := p.pending[evicted]; ok {
+				if err := p.writeBuffer(b); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// render formats an entry. Unbuffered entries have their group's title first, so interleaved
+// groups can be told apart.
+func (p *printer) render(group *dollop.Group, line dollop.Line) string {
+	text := printTimeStyle.Render(line.Timestamp.Format("15:04:05")) + " "
+
+	if p.opts.FlushAfter == 0 {
+		text += printGroupStyle.Render("["+group.Title+"]") + " "
+	}
+
+	return text + logLine{Line: line}.String(false)
+}
+
+func (p *printer) write(group *dollop.Group, text string) error {
+	if !p.opts.Colour {
+		text = ansiSequence.ReplaceAllString(text, "")
+	}
+
+	if p.opts.FlushAfter == 0 {
+		_, err := fmt.Fprintln(p.w, text)
+		return err
+	}
+
+	b, ok := p.pending[group]
+	if !ok {
+		b = &printBuffer{group: group}
+		p.pending[group] = b
+	}
+
+	b.lines = append(b.lines, text)
+	b.last = time.Now()
+
+	return nil
+}
+
+// flush writes the buffered groups which are ready, the ones which went quiet first written first.
+func (p *printer) flush(ready func(*printBuffer) bool) error {
+	buffers := []*printBuffer{}
+	for _, b := range p.pending {
+		if ready(b) {
+			buffers = append(buffers, b)
+		}
+	}
+
+	sort.Slice(buffers, func(i, j int) bool { return buffers[i].last.Before(buffers[j].last) })
+
+	for _, b := range buffers {
+		if err := p.writeBuffer(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *printer) writeBuffer(b *printBuffer) error {
+	delete(p.pending, b.group)
+
+	header := printTitleStyle.Render(b.group.Title) + " " + printTimeStyle.Render(b.group.Description)
+	if !p.opts.Colour {
+		header = ansiSequence.ReplaceAllString(header, "")
+	}
+
+	_, err := fmt.Fprintf(p.w, "%s\n%s\n\n", header, strings.Join(b.lines, "\n"))
+
+	return err
+}
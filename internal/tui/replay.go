@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/internal/input"
+)
+
+const (
+	// seekStep is how far the seek keys skip.
+	seekStep = 10 * time.Second
+
+	minReplaySpeed = 0.125
+	maxReplaySpeed = 1024
+)
+
+// controlReplay pauses, steps, seeks or changes the speed of a replay.
+func (m *Model) controlReplay(msg tea.KeyMsg) {
+	replay, ok := m.input.(input.Replayer)
+	if !ok {
+		return
+	}
+
+	speed, _, _ := replay.Status()
+
+	switch {
+	case key.Matches(msg, m.keyMap.ReplayPause):
+		replay.TogglePause()
+	case key.Matches(msg, m.keyMap.Step):
+		replay.Step()
+	case key.Matches(msg, m.keyMap.SeekBack):
+		replay.Seek(-seekStep)
+	case key.Matches(msg, m.keyMap.SeekForward):
+		replay.Seek(seekStep)
+	case key.Matches(msg, m.keyMap.Faster) && speed < maxReplaySpeed:
+		replay.SetSpeed(speed * 2)
+	case key.Matches(msg, m.keyMap.Slower) && speed > minReplaySpeed:
+		replay.SetSpeed(speed / 2)
+	}
+
+	m.SetStatus(m.statusLine)
+}
+
+// replayText describes where a replay is up to.
+func replayText(replay input.Replayer) string {
+	speed, position, paused := replay.Status()
+
+	state := formatSpeed(speed)
+	if paused {
+		state = "paused"
+	}
+
+	if position.IsZero() {
+		return fmt.Sprintf("replay %s", state)
+	}
+
+	return fmt.Sprintf("replay %s at %s", state, position.Format("15:04:05"))
+}
+
+// formatSpeed writes a replay speed like 4x or 0.5x.
+func formatSpeed(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "x"
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+func newSearchInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "level=error and status>=500, or empty to clear"
+
+	return input
+}
+
+// startSearch opens the search prompt, starting with the current query so it can be refined.
+func (m *Model) startSearch() tea.Cmd {
+	m.searching = true
+	m.searchErr = ""
+
+	if m.query != nil {
+		m.searchInput.SetValue(m.query.String())
+	} else {
+		m.searchInput.SetValue("")
+	}
+
+	m.searchInput.CursorEnd()
+	m.searchInput.Width = m.width - len(m.searchInput.Prompt) - 1
+
+	return m.searchInput.Focus()
+}
+
+// updateSearch handles keys while the search prompt is open.
+func (m *Model) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keyMap.Quit):
+		return tea.Quit
+
+	case msg.Type == tea.KeyEnter:
+		if err := m.applySearch(m.searchInput.Value()); err != nil {
+			m.searchErr = err.Error()
+			return nil
+		}
+
+		m.searching = false
+		m.searchInput.Blur()
+
+	case msg.Type == tea.KeyEsc:
+		m.searching = false
+		m.searchInput.Blur()
+
+	default:
+		m.searchErr = ""
+
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return cmd
+	}
+
+	return nil
+}
+
+// applySearch filters the groups and logs by the query, or removes the filter if it's empty.
+func (m *Model) applySearch(text string) error {
+	if strings.TrimSpace(text) == "" {
+		m.query = nil
+		m.matches = nil
+	} else {
+		query, err := dollop.ParseQuery(text)
+		if err != nil {
+			return err
+		}
+
+		m.query = query
+		m.matches = map[*dollop.Group]int{}
+
+		for _, item := range m.groups {
+			group := item.(*logGroup).group
+			m.matches[group] = query.CountMatches(group)
+		}
+	}
+
+	m.refreshGroups(true, nil)
+
+	lines, selLine := m.generateLogItems()
+	m.logs.SetItems(lines)
+	m.logs.Select(selLine)
+
+	m.SetStatus(m.statusLine)
+
+	return nil
+}
+
+// refreshGroups updates the groups list after the groups have been reordered or changed, keeping the
+// selection and hiding groups without any lines matching the search.
+func (m *Model) refreshGroups(reordered bool, changed map[*dollop.Group]bool) {
+	sel, _ := m.list.SelectedItem().(*logGroup)
+
+	if m.query != nil {
+		for group := range changed {
+			m.matches[group] = m.query.CountMatches(group)
+		}
+
+		// Any change can start or stop a group matching.
+		reordered = reordered || len(changed) > 0
+	}
+
+	if reordered {
+		items := m.visibleGroups()
+		m.list.SetItems(items)
+
+		for index, item := range items {
+			if item.(*logGroup) == sel {
+				m.list.Select(index)
+				break
+			}
+		}
+	}
+
+	// Only the selected group's lines are shown, so the logs list is left alone unless that changes.
+	current, _ := m.list.SelectedItem().(*logGroup)
+	if current != sel || (current != nil && changed[current.group]) {
+		lines, selLine := m.generateLogItems()
+		m.logs.SetItems(lines)
+		m.logs.Select(selLine)
+	}
+}
+
+// visibleGroups returns the groups with lines matching the search, or all of them without one.
+func (m *Model) visibleGroups() []list.Item {
+	if m.query == nil {
+		return m.groups
+	}
+
+	visible := []list.Item{}
+	matches := make(map[*dollop.Group]int, len(m.matches))
+
+	for _, item := range m.groups {
+		group := item.(*logGroup).group
+
+		// Evicted groups are no longer in the list, so their counts are dropped here.
+		matches[group] = m.matches[group]
+
+		if m.matches[group] > 0 {
+			visible = append(visible, item)
+		}
+	}
+
+	m.matches = matches
+
+	return visible
+}
+
+// matchesText describes how many lines match the search.
+func (m Model) matchesText() string {
+	lines, groups := 0, 0
+
+	for _, count := range m.matches {
+		if count > 0 {
+			lines += count
+			groups++
+		}
+	}
+
+	return fmt.Sprintf("%d matches in %d groups for %s", lines, groups, m.query.String())
+}
@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/elseano/dollop/internal/session"
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+// bookmark marks a line by its position in its group.
+type bookmark struct {
+	group *dollop.Group
+	line  int
+}
+
+// groupKey identifies a group across sessions. The built in groups have no value, but their titles
+// are distinct.
+func groupKey(g *dollop.Group) string {
+	if g.Value == "" {
+		return g.Title
+	}
+
+	return g.Value
+}
+
+// selectedLine returns the line shown in the detail view, or the one selected in the logs list.
+func (m Model) selectedLine() (logLine, bool) {
+	if m.focusLog != nil {
+		return *m.focusLog, true
+	}
+
+	line, ok := m.logs.SelectedItem().(logLine)
+
+	return line, ok
+}
+
+func (m *Model) toggleBookmark() {
+	group, ok := m.list.SelectedItem().(*logGroup)
+	if !ok {
+		return
+	}
+
+	line, ok := m.selectedLine()
+	if !ok {
+		return
+	}
+
+	b := bookmark{group: group.group, line: line.index}
+
+	if m.bookmarks[b] {
+		delete(m.bookmarks, b)
+		m.SetStatus("Bookmark removed")
+	} else {
+		m.bookmarks[b] = true
+		m.SetStatus("Bookmarked")
+	}
+
+	lines, selLine := m.generateLogItems()
+	m.logs.SetItems(lines)
+	m.logs.Select(selLine)
+}
+
+// nextBookmark selects the bookmark after the current line, in the order the groups are listed,
+// going back to the first after the last.
+func (m *Model) nextBookmark() {
+	positions := map[*dollop.Group]int{}
+	for index, item := range m.list.Items() {
+		positions[item.(*logGroup).group] = index
+	}
+
+	marks := []bookmark{}
+	for b := range m.bookmarks {
+		// Bookmarks in groups hidden by a search or evicted are skipped.
+		if _, ok := positions[b.group]; ok && b.line < len(b.group.Lines) && (m.query == nil || m.query.Match(b.group.Lines[b.line])) {
+			marks = append(marks, b)
+		}
+	}
+
+	if len(marks) == 0 {
+		m.SetStatus("No bookmarks")
+		return
+	}
+
+	before := func(a bookmark, b bookmark) bool {
+		if a.group != b.group {
+			return positions[a.group] < positions[b.group]
+		}
+
+		return a.line < b.line
+	}
+
+	sort.Slice(marks, func(i, j int) bool { return before(marks[i], marks[j]) })
+
+	next := marks[0]
+
+	if group, ok := m.list.SelectedItem().(*logGroup); ok {
+		if line, ok := m.selectedLine(); ok {
+			current := bookmark{group: group.group, line: line.index}
+
+			for _, b := range marks {
+				if before(current, b) {
+					next = b
+					break
+				}
+			}
+		}
+	}
+
+	m.stopFollowing()
+	m.selectLine(next.group, next.line)
+	m.focusOnLogs()
+}
+
+// selectLine selects a group in the groups list, and a line within it by its position in the group.
+func (m *Model) selectLine(group *dollop.Group, line int) {
+	for index, item := range m.list.Items() {
+		it := item.(*logGroup)
+		if it.group != group {
+			continue
+		}
+
+		m.list.Select(index)
+
+		lines, _ := m.generateLogItems()
+		for shown, l := range lines {
+			if l.(logLine).index == line {
+				it.selectedLine = shown
+				break
+			}
+		}
+
+		lines, selLine := m.generateLogItems()
+		m.logs.SetItems(lines)
+		m.logs.Select(selLine)
+
+		return
+	}
+}
+
+// shiftTrimmed moves the bookmarks and selections in groups which had lines trimmed from the start
+// by the retention limits, so they stay on the same lines. Bookmarks on trimmed lines are dropped.
+func (m *Model) shiftTrimmed(trimmed map[*dollop.Group]int) {
+	if len(trimmed) == 0 {
+		return
+	}
+
+	bookmarks := make(map[bookmark]bool, len(m.bookmarks))
+	for b := range m.bookmarks {
+		b.line -= trimmed[b.group]
+		if b.line >= 0 {
+			bookmarks[b] = true
+		}
+	}
+
+	m.bookmarks = bookmarks
+
+	selected, _ := m.list.SelectedItem().(*logGroup)
+
+	for _, item := range m.groups {
+		it := item.(*logGroup)
+		n := trimmed[it.group]
+
+		// Other groups remember the position of their selection among the lines shown, which is
+		// the line's index unless a search is hiding some.
+		if n > 0 && it != selected && m.query == nil {
+			it.selectedLine -= n
+			if it.selectedLine < 0 {
+				it.selectedLine = 0
+			}
+		}
+	}
+
+	if selected == nil || trimmed[selected.group] == 0 {
+		return
+	}
+
+	n := trimmed[selected.group]
+
+	if m.focusLog != nil {
+		m.focusLog.index -= n
+
+		// The line in the detail view has gone.
+		if m.focusLog.index < 0 {
+			m.focusOnLogs()
+		}
+	}
+
+	if line, ok := m.logs.SelectedItem().(logLine); ok {
+		index := line.index - n
+		if index < 0 {
+			index = 0
+		}
+
+		m.selectLine(selected.group, index)
+	}
+}
+
+// State returns the selection and bookmarks, for saving in a session.
+func (m Model) State() session.State {
+	state := session.State{}
+
+	present := map[*dollop.Group]bool{}
+	for _, item := range m.groups {
+		present[item.(*logGroup).group] = true
+	}
+
+	if group, ok := m.list.SelectedItem().(*logGroup); ok {
+		state.Group = groupKey(group.group)
+
+		if line, ok := m.selectedLine(); ok {
+			state.Line = line.index
+		}
+	}
+
+	for b := range m.bookmarks {
+		if present[b.group] {
+			state.Bookmarks = append(state.Bookmarks, session.Bookmark{Group: groupKey(b.group), Line: b.line})
+		}
+	}
+
+	sort.Slice(state.Bookmarks, func(i, j int) bool {
+		a, b := state.Bookmarks[i], state.Bookmarks[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+
+		return a.Line < b.Line
+	})
+
+	return state
+}
+
+// Restore sets a saved state to restore once the input has been read, such as when reopening a session.
+func (m *Model) Restore(state session.State) {
+	m.restore = &state
+}
+
+func (m *Model) restoreState(state session.State) {
+	groups := map[string]*dollop.Group{}
+	for _, item := range m.groups {
+		group := item.(*logGroup).group
+		groups[groupKey(group)] = group
+	}
+
+	for _, b := range state.Bookmarks {
+		if group, ok := groups[b.Group]; ok {
+			m.bookmarks[bookmark{group: group, line: b.Line}] = true
+		}
+	}
+
+	if group, ok := groups[state.Group]; ok {
+		m.selectLine(group, state.Line)
+	} else {
+		lines, selLine := m.generateLogItems()
+		m.logs.SetItems(lines)
+		m.logs.Select(selLine)
+	}
+}
@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// treeNode is a field in the detail view's metadata tree. Objects and arrays have children, which
+// are hidden while the node is collapsed.
+type treeNode struct {
+	key      string
+	value    interface{}
+	depth    int
+	parent   *treeNode
+	children []*treeNode
+	expanded bool
+}
+
+// metadataTree is the state of the detail view, which shows a line's data as a tree of fields, or
+// as raw JSON.
+type metadataTree struct {
+	data   map[string]interface{}
+	roots  []*treeNode
+	cursor int
+	raw    bool
+
+	// cursorStart and cursorEnd are the first and last lines the row under the cursor was drawn on
+	// in the last render, so it can be scrolled into view.
+	cursorStart, cursorEnd int
+}
+
+func newMetadataTree(data map[string]interface{}) *metadataTree {
+	return &metadataTree{data: data, roots: buildTreeNodes(data, nil, 0)}
+}
+
+func buildTreeNodes(value interface{}, parent *treeNode, depth int) []*treeNode {
+	nodes := []*treeNode{}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			nodes = append(nodes, newTreeNode(k, v[k], parent, depth))
+		}
+
+	case []interface{}:
+		for i, item := range v {
+			nodes = append(nodes, newTreeNode(fmt.Sprintf("[%d]", i), item, parent, depth))
+		}
+	}
+
+	return nodes
+}
+
+func newTreeNode(key string, value interface{}, parent *treeNode, depth int) *treeNode {
+	node := &treeNode{key: key, value: value, depth: depth, parent: parent, expanded: true}
+	node.children = buildTreeNodes(value, node, depth+1)
+
+	return node
+}
+
+// isContainer returns true for objects and arrays, even when empty.
+func (n *treeNode) isContainer() bool {
+	switch n.value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+
+	return false
+}
+
+// visible returns the nodes which aren't inside a collapsed node, in display order.
+func (t *metadataTree) visible() []*treeNode {
+	result := []*treeNode{}
+
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			result = append(result, n)
+
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+
+	walk(t.roots)
+
+	return result
+}
+
+func (t *metadataTree) current() *treeNode {
+	nodes := t.visible()
+	if t.cursor < 0 || t.cursor >= len(nodes) {
+		return nil
+	}
+
+	return nodes[t.cursor]
+}
+
+// move moves the cursor by delta rows, stopping at either end.
+func (t *metadataTree) move(delta int) {
+	t.cursor += delta
+
+	if count := len(t.visible()); t.cursor >= count {
+		t.cursor = count - 1
+	}
+
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+}
+
+// expand opens the node under the cursor.
+func (t *metadataTree) expand() {
+	if n := t.current(); n != nil && len(n.children) > 0 {
+		n.expanded = true
+	}
+}
+
+// collapse closes the node under the cursor, or moves to its parent if it's already closed.
+// It returns false when there's nothing left to collapse, at the top level.
+func (t *metadataTree) collapse() bool {
+	n := t.current()
+	if n == nil {
+		return false
+	}
+
+	if n.expanded && len(n.children) > 0 {
+		n.expanded = false
+		return true
+	}
+
+	if n.parent == nil {
+		return false
+	}
+
+	for i, v := range t.visible() {
+		if v == n.parent {
+			t.cursor = i
+			break
+		}
+	}
+
+	return true
+}
+
+var (
+	treeCursorStyle  = lipgloss.NewStyle().Background(selectedBackgroundColor)
+	treeMarkerStyle  = lipgloss.NewStyle().Foreground(dimColor)
+	treeSummaryStyle = lipgloss.NewStyle().Foreground(dimColor).Italic(true)
+)
+
+// render draws the tree, or the raw JSON, wrapped to the width.
+func (t *metadataTree) render(width int, startLine int) string {
+	if t.raw {
+		raw, err := json.MarshalIndent(t.data, "", "  ")
+		if err != nil {
+			return err.Error()
+		}
+
+		return wordwrap.String(string(raw), width)
+	}
+
+	var builder strings.Builder
+	line := startLine
+
+	for i, n := range t.visible() {
+		row := wordwrap.String(t.renderNode(n, i == t.cursor), width)
+
+		if i == t.cursor {
+			t.cursorStart = line
+			t.cursorEnd = line + strings.Count(row, "\n")
+		}
+
+		line += strings.Count(row, "\n") + 1
+
+		builder.WriteString(row)
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func (t *metadataTree) renderNode(n *treeNode, selected bool) string {
+	indent := strings.Repeat("  ", n.depth)
+
+	marker := "  "
+	if len(n.children) > 0 {
+		if n.expanded {
+			marker = "▾ "
+		} else {
+			marker = "▸ "
+		}
+	}
+
+	// Keys line up in a column, as far as the indentation allows.
+	keyWidth := 25 - len(indent) - lipgloss.Width(marker)
+	if keyWidth < len(n.key)+1 {
+		keyWidth = len(n.key) + 1
+	}
+
+	key := keyStyle.Copy().Width(keyWidth).Render(n.key)
+	if selected {
+		key = treeCursorStyle.Render(key)
+	}
+
+	row := indent + treeMarkerStyle.Render(marker) + key
+
+	switch {
+	case n.isContainer():
+		if !n.expanded || len(n.children) == 0 {
+			row += treeSummaryStyle.Render(summariseValue(n.value))
+		}
+
+	default:
+		value := formatValue(n.value)
+
+		if strings.Contains(value, "\n") {
+			// Multi-line values such as stack traces start on their own line, indented under the key.
+			valueIndent := indent + "    "
+			row += "\n" + dataStyle.Render(valueIndent+strings.ReplaceAll(value, "\n", "\n"+valueIndent))
+		} else {
+			row += dataStyle.Render(value)
+		}
+	}
+
+	return row
+}
+
+// summariseValue describes a collapsed object or array.
+func summariseValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("{%d %s}", len(v), plural(len(v), "field", "fields"))
+	case []interface{}:
+		return fmt.Sprintf("[%d %s]", len(v), plural(len(v), "item", "items"))
+	}
+
+	return ""
+}
+
+func plural(count int, one string, many string) string {
+	if count == 1 {
+		return one
+	}
+
+	return many
+}
+
+// formatValue shows a scalar field. Numbers from JSON are always floats, so whole numbers are shown
+// without a decimal point.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
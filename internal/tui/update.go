@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -8,10 +9,16 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/elseano/dollop/internal/input"
+	"github.com/elseano/dollop/internal/session"
+	"github.com/elseano/dollop/pkg/dollop"
 	"github.com/spf13/cast"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	groupsMutex.Lock()
+	defer groupsMutex.Unlock()
+
 	var (
 		cmds []tea.Cmd
 		cmd  tea.Cmd
@@ -39,7 +46,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.detail = viewport.New(m.rightSideWidth, height)
 			m.detail.MouseWheelEnabled = true
-			m.detail.SetContent(m.detailContent(m.detail.Width))
+			m.refreshDetail()
 		}
 
 		lines, selLine := m.generateLogItems()
@@ -62,53 +69,233 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
-		cmds = append(cmds, m.handleKeys(msg))
+		if m.searching {
+			cmds = append(cmds, m.updateSearch(msg))
+		} else if m.exporting != "" {
+			cmds = append(cmds, m.updateExport(msg))
+		} else {
+			cmds = append(cmds, m.handleKeys(msg))
+		}
 
-	case disconnectedMsg:
-		m.disconnected = true
-		m.SetStatus("Process has terminated")
+	case copiedMsg:
+		if msg.osc52 {
+			writeOSC52(msg.text)
+		}
+
+		m.SetStatus(fmt.Sprintf("Copied %s", msg.what))
+
+	case restartedMsg:
+		if msg.err != nil {
+			m.SetStatus(fmt.Sprintf("Restart failed: %s", msg.err.Error()))
+		} else {
+			m.disconnected = false
+			m.SetStatus("Process restarted")
+		}
 
 	case scanMsg:
-		sel, selected := m.list.SelectedItem().(*logGroup)
+		if m.paused {
+			m.holdUpdate(msg)
+		} else {
+			m.applyUpdate(msg)
+		}
 
-		m.list.SetItems(msg.lines)
+		cmds = append(cmds, m.waitForUpdate())
 
-		if selected {
-			for index, item := range msg.lines {
-				if sel.title == item.(*logGroup).title {
-					m.list.Select(index)
-					break
-				}
-			}
+	default:
+		// Anything else, such as the cursor blinking, belongs to the open prompt.
+		if m.searching {
+			m.searchInput, cmd = m.searchInput.Update(msg)
+			cmds = append(cmds, cmd)
+		} else if m.exporting != "" {
+			m.exportInput, cmd = m.exportInput.Update(msg)
+			cmds = append(cmds, cmd)
 		}
+	}
 
-		lines, selLine := m.generateLogItems()
-		m.logs.SetItems(lines)
-		m.logs.Select(selLine)
+	return m, tea.Batch(cmds...)
+}
 
-		if m.focus == "groups" {
-			m.setKeysForIndex(&m.list)
-		} else if m.focus == "logs" && m.focusLog == nil {
-			m.setKeysForIndex(&m.logs)
-		} else if m.focus == "" {
-			m.focusOnGroups()
+// applyUpdate shows the changes from the pipeline.
+func (m *Model) applyUpdate(msg scanMsg) {
+	m.shiftTrimmed(msg.trimmed)
+
+	var reloaded session.State
+
+	if msg.reloaded {
+		// Every group was replaced, so the selection and bookmarks are carried over by their keys.
+		reloaded = m.State()
+		m.bookmarks = map[bookmark]bool{}
+		m.matches = map[*dollop.Group]int{}
+
+		if m.focusLog != nil {
+			m.focusOnLogs()
 		}
+	}
+
+	if msg.groups != nil {
+		m.groups = msg.groups
+	}
+
+	m.refreshGroups(msg.groups != nil, msg.changed)
+
+	if msg.reloaded {
+		m.restoreState(reloaded)
+	}
+
+	if m.following {
+		m.followNewest()
+	}
+
+	if m.focus == "groups" {
+		m.setKeysForIndex(&m.list)
+	} else if m.focus == "logs" && m.focusLog == nil {
+		m.setKeysForIndex(&m.logs)
+	} else if m.focus == "" {
+		m.focusOnGroups()
+	}
 
-		if msg.status != "" {
-			m.SetStatus(msg.status)
-		} else if m.statusLine == "" {
-			m.SetStatus("Logs receiving")
+	m.evicted = msg.evicted
+
+	if msg.status != "" {
+		m.SetStatus(msg.status)
+	} else if m.statusLine == "" {
+		m.SetStatus("Logs receiving")
+	} else {
+		// The evicted count may have changed the width of the status.
+		m.SetStatus(m.statusLine)
+	}
+
+	if msg.rewound {
+		m.disconnected = false
+	}
+
+	if msg.err != nil {
+		m.disconnected = true
+
+		var exitErr *input.ExitError
+		if errors.As(msg.err, &exitErr) {
+			m.SetStatus(fmt.Sprintf("Process %s", exitErr.Error()))
+		} else {
+			m.SetStatus("Process has terminated")
 		}
 
-	default:
-		m.statusLine = fmt.Sprintf("Msg: %+v", msg)
+		// Everything in a reopened session has been read, so its selection can be restored.
+		if m.restore != nil {
+			m.restoreState(*m.restore)
+			m.restore = nil
+			m.SetStatus("Session restored")
+		}
 	}
 
-	if !m.disconnected {
-		cmds = append(cmds, m.scanLogs())
+	if msg.configErr != nil {
+		m.SetStatus(fmt.Sprintf("Config error: %s", msg.configErr.Error()))
+	} else if msg.rewound {
+		m.SetStatus("Replay rewound")
+	} else if msg.reloaded {
+		m.SetStatus("Config reloaded")
 	}
+}
 
-	return m, tea.Batch(cmds...)
+// holdUpdate merges an update into those held back while paused, so they can be shown on resume.
+func (m *Model) holdUpdate(msg scanMsg) {
+	if m.held == nil {
+		m.held = &scanMsg{changed: map[*dollop.Group]bool{}}
+	}
+
+	if msg.groups != nil {
+		m.held.groups = msg.groups
+	}
+
+	for group := range msg.changed {
+		m.held.changed[group] = true
+	}
+
+	for group, n := range msg.trimmed {
+		if m.held.trimmed == nil {
+			m.held.trimmed = map[*dollop.Group]int{}
+		}
+
+		m.held.trimmed[group] += n
+	}
+
+	if msg.status != "" {
+		m.held.status = msg.status
+	}
+
+	if msg.rewound {
+		m.held.rewound = true
+		m.held.err = nil
+	}
+
+	if msg.err != nil {
+		m.held.err = msg.err
+	}
+
+	if msg.reloaded {
+		m.held.reloaded = true
+		m.held.configErr = nil
+	}
+
+	if msg.configErr != nil {
+		m.held.configErr = msg.configErr
+	}
+
+	m.held.evicted = msg.evicted
+	m.newLines += msg.lines
+
+	m.SetStatus(m.statusLine)
+}
+
+// togglePause freezes the display, or shows everything which arrived while it was frozen.
+func (m *Model) togglePause() {
+	m.paused = !m.paused
+
+	if m.paused {
+		m.keyMap.Pause.SetHelp("p", "resume")
+	} else {
+		m.keyMap.Pause.SetHelp("p", "pause")
+
+		if m.held != nil {
+			m.applyUpdate(*m.held)
+		}
+
+		m.held = nil
+		m.newLines = 0
+	}
+
+	m.SetStatus(m.statusLine)
+}
+
+// setFollowing turns tailing the newest group and line on or off.
+func (m *Model) setFollowing(following bool) {
+	m.following = following
+
+	if following {
+		m.keyMap.Follow.SetHelp("F", "unfollow")
+		m.followNewest()
+	} else {
+		m.keyMap.Follow.SetHelp("F", "follow")
+	}
+
+	m.SetStatus(m.statusLine)
+}
+
+// followNewest selects the newest group and its last line, unless a line's detail is open.
+func (m *Model) followNewest() {
+	if m.focusLog != nil || len(m.list.Items()) == 0 {
+		return
+	}
+
+	m.list.Select(0)
+
+	lines, _ := m.generateLogItems()
+	if group, ok := m.list.SelectedItem().(*logGroup); ok && len(lines) > 0 {
+		group.selectedLine = len(lines) - 1
+	}
+
+	lines, selLine := m.generateLogItems()
+	m.logs.SetItems(lines)
+	m.logs.Select(selLine)
 }
 
 func (m *Model) handleKeys(msg tea.KeyMsg) tea.Cmd {
@@ -123,7 +310,64 @@ func (m *Model) handleKeys(msg tea.KeyMsg) tea.Cmd {
 		cmd = tea.Quit
 		cmds = append(cmds, cmd)
 
+	case key.Matches(msg, m.keyMap.Expand):
+		m.tree.expand()
+		m.refreshDetail()
+
+	case key.Matches(msg, m.keyMap.Collapse):
+		// At the top level there's nothing to collapse, so go back like escape would.
+		if m.tree.collapse() {
+			m.refreshDetail()
+		} else {
+			m.focusOnLogs()
+		}
+
+	case key.Matches(msg, m.keyMap.Raw):
+		m.tree.raw = !m.tree.raw
+		m.detail.GotoTop()
+		m.refreshDetail()
+
+	case key.Matches(msg, m.keyMap.CopyField):
+		cmds = append(cmds, m.copyField())
+
+	case key.Matches(msg, m.keyMap.CopyLine):
+		cmds = append(cmds, m.copyLine())
+
+	case key.Matches(msg, m.keyMap.CopyGroup):
+		cmds = append(cmds, m.copyGroup())
+
+	case key.Matches(msg, m.keyMap.Bookmark):
+		m.toggleBookmark()
+
+	case key.Matches(msg, m.keyMap.NextBookmark):
+		m.nextBookmark()
+
+	case key.Matches(msg, m.keyMap.Follow):
+		m.setFollowing(!m.following)
+
+	case key.Matches(msg, m.keyMap.Pause):
+		m.togglePause()
+
+	case key.Matches(msg, m.keyMap.ReplayPause, m.keyMap.Step, m.keyMap.SeekBack, m.keyMap.SeekForward,
+		m.keyMap.Faster, m.keyMap.Slower):
+		m.controlReplay(msg)
+
+	case key.Matches(msg, m.keyMap.Export):
+		cmds = append(cmds, m.startExport(exportGroup))
+
+	case key.Matches(msg, m.keyMap.ExportAll):
+		cmds = append(cmds, m.startExport(exportAll))
+
+	case key.Matches(msg, m.keyMap.Search):
+		cmds = append(cmds, m.startSearch())
+
+	case key.Matches(msg, m.keyMap.Restart):
+		m.SetStatus("Restarting process")
+		cmds = append(cmds, m.restartSource())
+
 	case key.Matches(msg, m.keyMap.GoToStart):
+		m.stopFollowing()
+
 		switch m.focus {
 		case "groups":
 			m.list.Select(0)
@@ -144,6 +388,10 @@ func (m *Model) handleKeys(msg tea.KeyMsg) tea.Cmd {
 		}
 
 	case key.Matches(msg, m.keyMap.CursorDown, m.keyMap.CursorUp, m.keyMap.NextPage, m.keyMap.PrevPage):
+		if m.focusLog == nil {
+			m.stopFollowing()
+		}
+
 		switch m.focus {
 		case "groups":
 			m.list, cmd = m.list.Update(msg)
@@ -154,7 +402,15 @@ func (m *Model) handleKeys(msg tea.KeyMsg) tea.Cmd {
 
 			m.setKeysForIndex(&m.list)
 		case "logs":
-			if m.focusLog != nil {
+			if m.focusLog != nil && !m.tree.raw && key.Matches(msg, m.keyMap.CursorDown, m.keyMap.CursorUp) {
+				if key.Matches(msg, m.keyMap.CursorDown) {
+					m.tree.move(1)
+				} else {
+					m.tree.move(-1)
+				}
+
+				m.refreshDetail()
+			} else if m.focusLog != nil {
 				m.detail, cmd = m.detail.Update(msg)
 				cmds = append(cmds, cmd)
 			} else {
@@ -203,6 +459,13 @@ func (m *Model) handleKeys(msg tea.KeyMsg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// stopFollowing turns off following when the selection is moved by hand, so it isn't snatched back.
+func (m *Model) stopFollowing() {
+	if m.following {
+		m.setFollowing(false)
+	}
+}
+
 func (m *Model) setKeysForIndex(l *list.Model) {
 	if l.Index() == 0 {
 		m.keyMap.CursorUp.SetEnabled(false)
@@ -237,29 +500,76 @@ func (m *Model) focusOnLogs() {
 	m.logs.Title = "Logs (active)"
 	m.list.Title = "Groups"
 	m.focusLog = nil
+	m.tree = nil
 
 	m.keyMap.Escape.SetEnabled(true)
+	m.keyMap.Escape.SetHelp("←/esc", "back")
 	m.keyMap.Select.SetEnabled(true)
+	m.keyMap.Expand.SetEnabled(false)
+	m.keyMap.Collapse.SetEnabled(false)
+	m.keyMap.Raw.SetEnabled(false)
+	m.keyMap.CopyField.SetEnabled(false)
+	m.keyMap.CopyLine.SetEnabled(false)
+	m.keyMap.CopyGroup.SetEnabled(false)
 	m.setKeysForIndex(&m.logs)
 	m.logs.SetDelegate(NewLogLineDelegate(true))
 }
 
 func (m *Model) focusOnLogItem(log *logLine) {
 	m.focusLog = log
+	m.tree = newMetadataTree(log.Data)
 
 	m.detail = viewport.New(m.rightSideWidth, m.height-1)
 	m.detail.MouseWheelEnabled = true
-	m.detail.SetContent(m.detailContent(m.detail.Width))
+	m.refreshDetail()
 
 	m.keyMap.Select.SetEnabled(false)
+	m.keyMap.CursorUp.SetEnabled(true)
+	m.keyMap.CursorDown.SetEnabled(true)
+	m.keyMap.PrevPage.SetEnabled(true)
+	m.keyMap.NextPage.SetEnabled(true)
+	m.keyMap.Escape.SetHelp("esc", "back")
+	m.keyMap.Expand.SetEnabled(true)
+	m.keyMap.Collapse.SetEnabled(true)
+	m.keyMap.Raw.SetEnabled(true)
+	m.keyMap.CopyField.SetEnabled(true)
+	m.keyMap.CopyLine.SetEnabled(true)
+	m.keyMap.CopyGroup.SetEnabled(true)
+}
+
+// refreshDetail redraws the detail view, scrolling to keep the tree's cursor visible.
+func (m *Model) refreshDetail() {
+	m.detail.SetContent(m.detailContent(m.detail.Width))
+
+	if m.tree == nil || m.tree.raw {
+		return
+	}
+
+	if m.tree.cursorStart < m.detail.YOffset {
+		m.detail.SetYOffset(m.tree.cursorStart)
+	} else if m.tree.cursorEnd >= m.detail.YOffset+m.detail.Height {
+		m.detail.SetYOffset(m.tree.cursorEnd - m.detail.Height + 1)
+	}
 }
 
 func (m Model) generateLogItems() ([]list.Item, int) {
 	result := []list.Item{}
 
 	if it, ok := m.list.SelectedItem().(*logGroup); ok && it != nil {
-		for _, line := range it.lines {
-			result = append(result, line)
+		for index, line := range it.group.Lines {
+			if m.query == nil || m.query.Match(line) {
+				bookmarked := m.bookmarks[bookmark{group: it.group, line: index}]
+				result = append(result, logLine{Line: line, index: index, bookmarked: bookmarked})
+			}
+		}
+
+		// Lines can be trimmed from the group, or hidden by a search, since the selection was made.
+		if it.selectedLine >= len(result) {
+			it.selectedLine = len(result) - 1
+		}
+
+		if it.selectedLine < 0 {
+			it.selectedLine = 0
 		}
 
 		return result, it.selectedLine
@@ -270,5 +580,31 @@ func (m Model) generateLogItems() ([]list.Item, int) {
 
 func (m *Model) SetStatus(status string) {
 	m.statusLine = status
-	m.Help.Width = m.width - (len(m.statusLine) + 3)
+	m.Help.Width = m.width - (len(m.statusText()) + 3)
+}
+
+// statusText is the status line, along with how many groups have been evicted by the retention limits
+// and how many lines match the search.
+func (m Model) statusText() string {
+	status := m.statusLine
+
+	if m.evicted > 0 {
+		status = fmt.Sprintf("%s (%d groups evicted)", status, m.evicted)
+	}
+
+	if m.query != nil {
+		status = fmt.Sprintf("%s | %s", status, m.matchesText())
+	}
+
+	if replay, ok := m.input.(input.Replayer); ok {
+		status = fmt.Sprintf("%s | %s", status, replayText(replay))
+	}
+
+	if m.paused {
+		status = fmt.Sprintf("%s | paused, +%d new", status, m.newLines)
+	} else if m.following {
+		status = fmt.Sprintf("%s | following", status)
+	}
+
+	return status
 }
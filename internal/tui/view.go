@@ -1,8 +1,6 @@
 package tui
 
 import (
-	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -17,6 +15,9 @@ var (
 
 	dividerStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"})
+
+	promptErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#fd4b4b", Dark: "#fd4b4b"})
 )
 
 func (m Model) listView() string {
@@ -28,7 +29,23 @@ func (m Model) logsView() string {
 }
 
 func (m Model) statusView() string {
-	return m.statusLine + " " + m.Help.View(m.keyMap)
+	if m.searching {
+		if m.searchErr != "" {
+			return m.searchInput.View() + " " + promptErrorStyle.Render(m.searchErr)
+		}
+
+		return m.searchInput.View()
+	}
+
+	if m.exporting != "" {
+		if m.exportErr != "" {
+			return m.exportInput.View() + " " + promptErrorStyle.Render(m.exportErr)
+		}
+
+		return m.exportInput.View()
+	}
+
+	return m.statusText() + " " + m.Help.View(m.keyMap)
 }
 
 func (m Model) detailView() string {
@@ -36,6 +53,9 @@ func (m Model) detailView() string {
 }
 
 func (m Model) View() string {
+	groupsMutex.Lock()
+	defer groupsMutex.Unlock()
+
 	if m.focusLog == nil {
 		return lipgloss.JoinVertical(lipgloss.Left,
 			lipgloss.JoinHorizontal(lipgloss.Top, m.listView(), m.logsView()),
@@ -88,93 +108,44 @@ var dataStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D
 var tagNameStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#4e51b7", Dark: "#484cb0"})
 var tagSoloStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#5db6d7", Dark: "#5db6d7"})
 var tagValueStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999999", Dark: "#999999"})
+var badTimeStyle = lipgloss.NewStyle().Foreground(warningColor)
 
 func (m Model) detailContent(width int) string {
 	var builder strings.Builder
-	if it := m.logs.SelectedItem(); it != nil {
-		line := it.(logLine)
+	if m.focusLog != nil {
+		line := *m.focusLog
 
 		builder.WriteString(line.String(false))
 		builder.WriteString("\n\n")
-		builder.WriteString(renderMetadata(line.data, 0))
-	}
-
-	return wordwrap.String(builder.String(), width)
-}
-
-func trimString(s string, length int) string {
-	if len(s) > length {
-		return s[0:length]
-	}
-	return s
-}
-
-func renderMetadata(s interface{}, indentLevel int) string {
-	switch s := s.(type) {
-	case string:
-		return s
-
-	case int:
-		return fmt.Sprintf("%d", s)
 
-	case float64:
-		return fmt.Sprintf("%f", s)
-
-	case []string:
-		return strings.Join(s, ", ")
-
-	case []int:
-		b := []string{}
-		for _, i := range s {
-			b = append(b, fmt.Sprintf("%d", i))
-		}
-
-		return strings.Join(b, ", ")
-
-	case []float64:
-		b := []string{}
-		for _, i := range s {
-			b = append(b, fmt.Sprintf("%f", i))
+		builder.WriteString(keyStyle.Render("time"))
+		builder.WriteString(dataStyle.Render(line.Timestamp.Format("2006-01-02 15:04:05.000 MST")))
+		if line.TimestampFailed {
+			builder.WriteString(badTimeStyle.Render(" (when read, as the timestamp couldn't be parsed)"))
 		}
+		builder.WriteString("\n")
 
-		return strings.Join(b, ", ")
-
-	case map[string]interface{}:
-		keys := []string{}
-		for k, _ := range s {
-			keys = append(keys, k)
-		}
-
-		sort.Strings(keys)
-
-		builder := strings.Builder{}
-
-		indentWidth := 8 * indentLevel
-		indentStr := strings.Repeat(" ", indentWidth)
-
-		if indentWidth > 0 {
-			// Move to next line before writing key values, as we've already written the key of the parent.
+		if line.Source != "" {
+			builder.WriteString(keyStyle.Render("source"))
+			builder.WriteString(dataStyle.Render(line.Source))
 			builder.WriteString("\n")
 		}
 
-		for _, k := range keys {
-			builder.WriteString(indentStr)
-			builder.WriteString(keyStyle.Render(k))
-			builder.WriteString(dataStyle.Render(renderMetadata(s[k], indentLevel+1)))
-			builder.WriteString("\n")
-		}
+		builder.WriteString("\n")
+	}
 
-		return builder.String()
+	header := wordwrap.String(builder.String(), width)
 
-	case []interface{}:
-		b := []string{}
-		for _, i := range s {
-			b = append(b, fmt.Sprintf("%v", i))
-		}
+	if m.tree == nil {
+		return header
+	}
 
-		return strings.Join(b, ", ")
+	return header + m.tree.render(width, strings.Count(header, "\n"))
+}
 
+func trimString(s string, length int) string {
+	if len(s) > length {
+		return s[0:length]
 	}
-
-	return fmt.Sprintf("%T", s)
+	return s
 }
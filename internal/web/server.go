@@ -0,0 +1,302 @@
+// Package web serves the grouped logs to a browser, as an alternative to the terminal UI.
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elseano/dollop/pkg/dollop"
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// notifyInterval limits how often browsers are told to refresh while lines are arriving.
+const notifyInterval = 250 * time.Millisecond
+
+// tallyLevels are the levels counted against each group, as in the UI's groups list.
+var tallyLevels = []string{"trace", "debug", "info", "warning", "error", "fatal"}
+
+// Server serves the processor's groups over HTTP, telling connected browsers when they change.
+type Server struct {
+	processor *dollop.Processor
+	// lock guards the processor, which is shared with whatever is feeding it lines.
+	lock sync.Locker
+
+	// ids gives each group a stable identifier for the browser. It's guarded by lock.
+	ids    map[*dollop.Group]int
+	nextID int
+
+	clientsMutex sync.Mutex
+	clients      map[chan struct{}]struct{}
+	updates      chan struct{}
+}
+
+// NewServer creates a Server for a processor, which must only be used while holding lock.
+func NewServer(processor *dollop.Processor, lock sync.Locker) *Server {
+	s := &Server{
+		processor: processor,
+		lock:      lock,
+		ids:       map[*dollop.Group]int{},
+		clients:   map[chan struct{}]struct{}{},
+		updates:   make(chan struct{}, 1),
+	}
+
+	go s.broadcast()
+
+	return s
+}
+
+// Update tells the server the groups have changed. It never blocks.
+func (s *Server) Update() {
+	select {
+	case s.updates <- struct{}{}:
+	default:
+	}
+}
+
+// Serve serves the web UI to connections from the listener.
+func (s *Server) Serve(listener net.Listener) error {
+	return http.Serve(listener, s.Handler())
+}
+
+// Handler returns the routes for the web UI and its API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/api/groups", s.handleGroups)
+	mux.HandleFunc("/api/groups/", s.handleLines)
+	mux.HandleFunc("/api/events", s.handleEvents)
+
+	return mux
+}
+
+// broadcast passes updates on to each browser, at most once per notifyInterval.
+func (s *Server) broadcast() {
+	for range s.updates {
+		s.clientsMutex.Lock()
+		for client := range s.clients {
+			select {
+			case client <- struct{}{}:
+			default:
+			}
+		}
+		s.clientsMutex.Unlock()
+
+		time.Sleep(notifyInterval)
+	}
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexPage)
+}
+
+type groupJSON struct {
+	ID          int            `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Sources     []string       `json:"sources"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Lines       int            `json:"lines"`
+	Matches     int            `json:"matches"`
+	Levels      map[string]int `json:"levels"`
+}
+
+type groupsJSON struct {
+	Groups  []groupJSON `json:"groups"`
+	Evicted int         `json:"evicted"`
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseQuery(w, r)
+	if !ok {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := groupsJSON{Groups: []groupJSON{}, Evicted: s.processor.EvictedCount()}
+	ids := make(map[*dollop.Group]int, len(s.ids))
+
+	for _, g := range s.processor.Groups() {
+		id, ok := s.ids[g]
+		if !ok {
+			s.nextID++
+			id = s.nextID
+		}
+
+		// Evicted groups aren't carried over, so their ids are forgotten.
+		ids[g] = id
+
+		matches := len(g.Lines)
+		if query != nil {
+			matches = query.CountMatches(g)
+		}
+
+		if matches == 0 {
+			continue
+		}
+
+		tally := g.TallyLevels()
+		levels := map[string]int{}
+
+		for _, level := range tallyLevels {
+			if tally[level] > 0 {
+				levels[level] = tally[level]
+			}
+		}
+
+		result.Groups = append(result.Groups, groupJSON{
+			ID:          id,
+			Title:       g.Title,
+			Description: g.Description,
+			Sources:     g.Sources,
+			Timestamp:   g.Timestamp,
+			Lines:       len(g.Lines),
+			Matches:     matches,
+			Levels:      levels,
+		})
+	}
+
+	s.ids = ids
+
+	writeJSON(w, result)
+}
+
+type lineJSON struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Source    string                 `json:"source,omitempty"`
+	Tags      []dollop.Tag           `json:"tags"`
+	Data      map[string]interface{} `json:"data"`
+	Raw       string                 `json:"raw,omitempty"`
+	// TimestampFailed is set when Timestamp is when the line was read, as its own couldn't be parsed.
+	TimestampFailed bool `json:"timestampFailed,omitempty"`
+}
+
+// handleLines serves the lines of the group in the path, such as /api/groups/3/lines.
+func (s *Server) handleLines(w http.ResponseWriter, r *http.Request) {
+	idText := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/groups/"), "/lines")
+
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query, ok := parseQuery(w, r)
+	if !ok {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for g, groupID := range s.ids {
+		if groupID != id {
+			continue
+		}
+
+		lines := []lineJSON{}
+
+		for _, line := range g.Lines {
+			if query != nil && !query.Match(line) {
+				continue
+			}
+
+			lines = append(lines, lineJSON{
+				Timestamp: line.Timestamp,
+				Level:     line.Level,
+				Message:   line.Message,
+				Source:    line.Source,
+				Tags:      line.Tags,
+				Data:      line.Record(),
+				Raw:       line.Raw,
+
+				TimestampFailed: line.TimestampFailed,
+			})
+		}
+
+		writeJSON(w, lines)
+		return
+	}
+
+	http.Error(w, "group not found, it may have been evicted", http.StatusNotFound)
+}
+
+// handleEvents streams a server sent event each time the groups change.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+
+	s.clientsMutex.Lock()
+	s.clients[client] = struct{}{}
+	s.clientsMutex.Unlock()
+
+	defer func() {
+		s.clientsMutex.Lock()
+		delete(s.clients, client)
+		s.clientsMutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, "event: update\ndata: {}\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-client:
+			fmt.Fprint(w, "event: update\ndata: {}\n\n")
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// parseQuery reads the search from the q parameter, writing an error response if it's invalid.
+func parseQuery(w http.ResponseWriter, r *http.Request) (*dollop.Query, bool) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		return nil, true
+	}
+
+	query, err := dollop.ParseQuery(text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return query, true
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
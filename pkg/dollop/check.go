@@ -0,0 +1,242 @@
+package dollop
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/elseano/dollop/internal/parser"
+	"github.com/elseano/dollop/internal/templating"
+)
+
+// Problem is something wrong with a config, found by Check.
+type Problem struct {
+	// Path locates the setting, such as groups[1].titleField.
+	Path    string
+	Message string
+}
+
+func (p Problem) Error() string {
+	if p.Path == "" {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// checker collects the problems found in a config, rather than stopping at the first.
+type checker struct {
+	problems []Problem
+}
+
+func (c *checker) add(path string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the first problem, or nil if there weren't any.
+func (c *checker) err() error {
+	if len(c.problems) == 0 {
+		return nil
+	}
+
+	return c.problems[0]
+}
+
+func (c *checker) required(path string, value string) bool {
+	if value == "" {
+		c.add(path, "cannot be blank")
+		return false
+	}
+
+	return true
+}
+
+// field checks a field name, or a template when it contains "{{".
+func (c *checker) field(path string, value string) {
+	if !c.required(path, value) {
+		return
+	}
+
+	if _, err := templating.ParseTemplate(value); err != nil {
+		c.add(path, "invalid template: %s", err)
+	}
+}
+
+func (c *checker) template(path string, value string) {
+	if !c.required(path, value) {
+		return
+	}
+
+	if _, err := templating.ParseTemplateText(value); err != nil {
+		c.add(path, "invalid template: %s", err)
+	}
+}
+
+func (c *checker) regexp(path string, value string) *regexp.Regexp {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		c.add(path, "not a valid regular expression: %s", err)
+		return nil
+	}
+
+	return re
+}
+
+// join adds a setting onto a path, which is empty when checking a spec on its own.
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// Check returns every problem with the config, including templates which don't parse.
+func (c Config) Check() []Problem {
+	ch := &checker{}
+	c.check(ch)
+
+	return ch.problems
+}
+
+// Validate returns the first problem with the config, or nil if there aren't any.
+func (c Config) Validate() error {
+	ch := &checker{}
+	c.check(ch)
+
+	return ch.err()
+}
+
+func (c Config) check(ch *checker) {
+	ch.field("levelField", c.LevelField)
+	ch.field("messageField", c.MessageField)
+	ch.field("timestampField", c.TimestampField)
+
+	if err := checkTimestampFormat(c.TimestampFormat); err != nil {
+		ch.add("timestampFormat", "%s", err)
+	}
+
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			ch.add("timezone", "%s", err)
+		}
+	}
+
+	if c.MaxLines < 0 {
+		ch.add("maxLines", "cannot be negative")
+	}
+
+	if c.MaxGroups < 0 {
+		ch.add("maxGroups", "cannot be negative")
+	}
+
+	if c.MaxAge < 0 {
+		ch.add("maxAge", "cannot be negative")
+	}
+
+	for i, g := range c.Groups {
+		g.check(ch, fmt.Sprintf("groups[%d]", i))
+	}
+
+	for i, s := range c.Statuses {
+		s.check(ch, fmt.Sprintf("statuses[%d]", i))
+	}
+
+	for i, t := range c.Tags {
+		t.check(ch, fmt.Sprintf("tags[%d]", i))
+	}
+
+	for i, f := range c.Formats {
+		f.check(ch, fmt.Sprintf("formats[%d]", i))
+	}
+
+	for i, cont := range c.Continuations {
+		cont.check(ch, fmt.Sprintf("continuations[%d]", i))
+	}
+}
+
+func (g GroupSpec) Validate() error {
+	ch := &checker{}
+	g.check(ch, "")
+
+	return ch.err()
+}
+
+func (g GroupSpec) check(ch *checker, path string) {
+	ch.field(join(path, "titleField"), g.TitleField)
+	ch.field(join(path, "valueField"), g.ValueField)
+	ch.required(join(path, "name"), g.Name)
+
+	for i, t := range g.Tags {
+		t.check(ch, join(path, fmt.Sprintf("tags[%d]", i)))
+	}
+}
+
+func (s StatusSpec) Validate() error {
+	ch := &checker{}
+	s.check(ch, "")
+
+	return ch.err()
+}
+
+func (s StatusSpec) check(ch *checker, path string) {
+	ch.field(join(path, "display"), s.Display)
+}
+
+func (f FormatSpec) Validate() error {
+	ch := &checker{}
+	f.check(ch, "")
+
+	return ch.err()
+}
+
+func (f FormatSpec) check(ch *checker, path string) {
+	if f.formatType() == "" {
+		ch.add(join(path, "type"), "'type' or 'pattern' must be specified")
+		return
+	}
+
+	if f.Match != "" {
+		ch.regexp(join(path, "match"), f.Match)
+	}
+
+	var pattern *regexp.Regexp
+
+	if f.Pattern != "" {
+		if pattern = ch.regexp(join(path, "pattern"), f.Pattern); pattern == nil {
+			return
+		}
+	}
+
+	if _, err := parser.New(f.formatType(), pattern); err != nil {
+		ch.add(join(path, "type"), "%s", err)
+	}
+}
+
+func (c ContinuationSpec) Validate() error {
+	ch := &checker{}
+	c.check(ch, "")
+
+	return ch.err()
+}
+
+func (c ContinuationSpec) check(ch *checker, path string) {
+	if ch.required(join(path, "match"), c.Match) {
+		ch.regexp(join(path, "match"), c.Match)
+	}
+}
+
+func (s TagSpec) Validate() error {
+	ch := &checker{}
+	s.check(ch, "")
+
+	return ch.err()
+}
+
+func (s TagSpec) check(ch *checker, path string) {
+	ch.template(join(path, "key"), s.Key)
+
+	if s.Value != "" {
+		ch.field(join(path, "value"), s.Value)
+	}
+}
@@ -0,0 +1,265 @@
+package dollop
+
+import (
+	"fmt"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/elseano/dollop/internal/parser"
+	"github.com/elseano/dollop/internal/templating"
+)
+
+// Config describes how lines are parsed, grouped and tagged. Fields are given by name, using dots
+// for nested values, or as Go templates. The dollop command reads it from .dollop.yml.
+type Config struct {
+	LevelField     string `yaml:"levelField"`
+	MessageField   string `yaml:"messageField"`
+	TimestampField string `yaml:"timestampField"`
+
+	// TimestampFormat is how the timestamp is written: auto (the default) to recognise common
+	// formats, rfc3339 or rfc3339nano, unix, unix_ms or unix_ns for the time since the epoch, or a
+	// Go or strftime layout such as "2006-01-02 15:04:05" or "%d/%m/%Y %H:%M:%S".
+	TimestampFormat string `yaml:"timestampFormat"`
+
+	// Timezone is the zone times are shown in, such as UTC or Europe/London, which is also used for
+	// timestamps without a zone of their own. Defaults to the local zone for those, and showing
+	// times in the zone they were written in.
+	Timezone string `yaml:"timezone"`
+
+	// Levels renames levels, such as the numbers used by pino and bunyan, to the names Dollop
+	// shows. Levels are matched ignoring case.
+	Levels map[string]string `yaml:"levels"`
+
+	Groups        []*GroupSpec        `yaml:"groups"`
+	Statuses      []*StatusSpec       `yaml:"statuses"`
+	Tags          []*TagSpec          `yaml:"tags"`
+	Formats       []*FormatSpec       `yaml:"formats"`
+	Continuations []*ContinuationSpec `yaml:"continuations"`
+
+	// Retention limits. Zero means unlimited.
+	MaxLines  int           `yaml:"maxLines"`
+	MaxGroups int           `yaml:"maxGroups"`
+	MaxAge    time.Duration `yaml:"maxAge"`
+	PinErrors bool          `yaml:"pinErrors"`
+
+	levelTmpl     *template.Template
+	messageTmpl   *template.Template
+	timestampTmpl *template.Template
+	// timestampLayout is the Go layout for a TimestampFormat which isn't one of the named formats.
+	timestampLayout string
+	// location is the loaded Timezone, or nil without one.
+	location *time.Location
+}
+
+// GroupSpec groups lines by the value of ValueField, showing the group with TitleField. Name
+// describes the kind of group, such as Request.
+type GroupSpec struct {
+	ValueField string     `yaml:"valueField"`
+	TitleField string     `yaml:"titleField"`
+	Tags       []*TagSpec `yaml:"tags"`
+	Name       string     `yaml:"name"`
+
+	titleTmpl *template.Template
+	valueTmpl *template.Template
+}
+
+// StatusSpec shows Display as the status when it isn't blank for a line.
+type StatusSpec struct {
+	Display string `yaml:"display"`
+
+	displayTmpl *template.Template
+}
+
+// FormatSpec picks how a line is parsed. Formats are tried in order until one parses the line.
+// When Match is set, only lines matching it are considered, and the matched text is stripped
+// before parsing. Named captures in Match are added to the parsed fields.
+//
+// Pattern defines a custom format, where the named captures become the fields. Type defaults
+// to "regex" when a pattern is given.
+type FormatSpec struct {
+	Name    string `yaml:"name"`
+	Match   string `yaml:"match"`
+	Type    string `yaml:"type"`
+	Pattern string `yaml:"pattern"`
+
+	matchRegexp   *regexp.Regexp
+	patternRegexp *regexp.Regexp
+	parse         parser.Func
+}
+
+// ContinuationSpec matches lines which belong to the previous entry, such as the lines of a stack trace.
+// Matching lines are appended to Field on the previous entry from the same source, which defaults
+// to "stacktrace".
+type ContinuationSpec struct {
+	Match string `yaml:"match"`
+	Field string `yaml:"field"`
+
+	matchRegexp *regexp.Regexp
+}
+
+// TagSpec tags a line with Key when it isn't blank, and the value of the Value field if one is given.
+type TagSpec struct {
+	Value string `yaml:"source"`
+	Key   string `yaml:"name"`
+
+	valueTmpl *template.Template
+	keyTmpl   *template.Template
+}
+
+// prepare validates the config, then builds its templates and formats.
+func (c *Config) prepare() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.prepareTemplates(); err != nil {
+		return err
+	}
+
+	return c.prepareFormats()
+}
+
+func (c *Config) prepareTemplates() error {
+	var err error
+
+	if c.messageTmpl, err = templating.ParseTemplate(c.MessageField); err != nil {
+		return fmt.Errorf("messageField: %w", err)
+	}
+
+	if c.timestampTmpl, err = templating.ParseTemplate(c.TimestampField); err != nil {
+		return fmt.Errorf("timestampField: %w", err)
+	}
+
+	if c.levelTmpl, err = templating.ParseTemplate(c.LevelField); err != nil {
+		return fmt.Errorf("levelField: %w", err)
+	}
+
+	if c.Tags == nil {
+		c.Tags = []*TagSpec{}
+	}
+
+	for i, t := range c.Tags {
+		if err := t.prepareTemplates(); err != nil {
+			return fmt.Errorf("tags[%d].%w", i, err)
+		}
+	}
+
+	for i, g := range c.Groups {
+		if err := g.prepareTemplates(); err != nil {
+			return fmt.Errorf("groups[%d].%w", i, err)
+		}
+	}
+
+	for i, s := range c.Statuses {
+		if s.displayTmpl, err = templating.ParseTemplate(s.Display); err != nil {
+			return fmt.Errorf("statuses[%d].display: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *GroupSpec) prepareTemplates() error {
+	var err error
+
+	if g.valueTmpl, err = templating.ParseTemplate(g.ValueField); err != nil {
+		return fmt.Errorf("valueField: %w", err)
+	}
+
+	if g.titleTmpl, err = templating.ParseTemplate(g.TitleField); err != nil {
+		return fmt.Errorf("titleField: %w", err)
+	}
+
+	if g.Tags == nil {
+		g.Tags = []*TagSpec{}
+	}
+
+	for i, t := range g.Tags {
+		if err := t.prepareTemplates(); err != nil {
+			return fmt.Errorf("tags[%d].%w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *TagSpec) prepareTemplates() error {
+	var err error
+
+	if t.keyTmpl, err = templating.ParseTemplateText(t.Key); err != nil {
+		return fmt.Errorf("key: %w", err)
+	}
+
+	if t.Value != "" {
+		if t.valueTmpl, err = templating.ParseTemplate(t.Value); err != nil {
+			return fmt.Errorf("value: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) prepareFormats() error {
+	var err error
+
+	c.timestampLayout = timestampLayout(c.TimestampFormat)
+
+	if c.Timezone != "" {
+		if c.location, err = time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+	}
+
+	if len(c.Formats) == 0 {
+		c.Formats = []*FormatSpec{{Name: "auto", Type: "auto"}}
+	}
+
+	for i, f := range c.Formats {
+		if err := f.prepare(); err != nil {
+			return fmt.Errorf("formats[%d].%w", i, err)
+		}
+	}
+
+	for i, cont := range c.Continuations {
+		if cont.matchRegexp, err = regexp.Compile(cont.Match); err != nil {
+			return fmt.Errorf("continuations[%d].match: %w", i, err)
+		}
+
+		if cont.Field == "" {
+			cont.Field = "stacktrace"
+		}
+	}
+
+	return nil
+}
+
+func (f *FormatSpec) prepare() error {
+	var err error
+
+	if f.Match != "" {
+		if f.matchRegexp, err = regexp.Compile(f.Match); err != nil {
+			return fmt.Errorf("match: %w", err)
+		}
+	}
+
+	if f.Pattern != "" {
+		if f.patternRegexp, err = regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("pattern: %w", err)
+		}
+	}
+
+	if f.parse, err = parser.New(f.formatType(), f.patternRegexp); err != nil {
+		return fmt.Errorf("type: %w", err)
+	}
+
+	return nil
+}
+
+func (f FormatSpec) formatType() string {
+	if f.Type == "" && f.Pattern != "" {
+		return parser.RegexType
+	}
+
+	return f.Type
+}
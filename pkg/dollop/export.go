@@ -0,0 +1,229 @@
+package dollop
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatText   = "text"
+)
+
+// DefaultColumns are the CSV columns used when none are chosen.
+var DefaultColumns = []string{"time", "level", "msg"}
+
+// ExportOptions controls what Export writes.
+type ExportOptions struct {
+	// Format is one of FormatNDJSON, FormatJSON, FormatCSV or FormatText.
+	Format string
+	// Columns are the fields written as CSV columns, looked up as they are in a Query.
+	Columns []string
+	// Query limits the export to the matching lines when set.
+	Query *Query
+}
+
+// ExportFormatFor picks the export format from a file's extension, defaulting to NDJSON.
+func ExportFormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".csv":
+		return FormatCSV
+	case ".txt", ".log":
+		return FormatText
+	}
+
+	return FormatNDJSON
+}
+
+// Export writes the lines of the groups, in order.
+func Export(w io.Writer, groups []*Group, opts ExportOptions) error {
+	switch opts.Format {
+	case FormatNDJSON, "":
+		return WriteNDJSON(w, exportLines(groups, opts.Query))
+
+	case FormatJSON:
+		records := []map[string]interface{}{}
+		for _, line := range exportLines(groups, opts.Query) {
+			records = append(records, line.Record())
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(records)
+
+	case FormatCSV:
+		return writeCSV(w, exportLines(groups, opts.Query), opts.Columns)
+
+	case FormatText:
+		return writeText(w, groups, opts.Query)
+	}
+
+	return fmt.Errorf("unknown export format %q, expected one of %s", opts.Format, strings.Join([]string{FormatNDJSON, FormatJSON, FormatCSV, FormatText}, ", "))
+}
+
+func exportLines(groups []*Group, query *Query) []Line {
+	lines := []Line{}
+
+	for _, g := range groups {
+		for _, line := range g.Lines {
+			if query == nil || query.Match(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return lines
+}
+
+// Record returns the line's fields, for writing out as JSON. Lines which couldn't be parsed only
+// have their message and the time they were read.
+func (l Line) Record() map[string]interface{} {
+	if l.Data != nil {
+		return l.Data
+	}
+
+	return map[string]interface{}{
+		"msg":  l.Message,
+		"time": l.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+// WriteNDJSON writes each line's record as JSON, one per line.
+func WriteNDJSON(w io.Writer, lines []Line) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	for _, line := range lines {
+		if err := encoder.Encode(line.Record()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, lines []Line, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+
+	for _, line := range lines {
+		for i, column := range columns {
+			value, ok := lookupField(line, column)
+			if ok {
+				row[i] = formatField(value)
+			} else {
+				row[i] = ""
+			}
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// formatField writes a field as text. Whole numbers have no decimal point, and objects and arrays
+// are written as JSON.
+func formatField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return string(raw)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// writeText writes each group's title followed by its lines, laid out as they are in the logs list.
+func writeText(w io.Writer, groups []*Group, query *Query) error {
+	for _, g := range groups {
+		lines := exportLines([]*Group{g}, query)
+		if len(lines) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "== %s (%s)\n", g.Title, g.Description); err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line.String()); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String formats the line as plain text: its time, level, message and tags.
+func (l Line) String() string {
+	builder := strings.Builder{}
+
+	builder.WriteString(l.Timestamp.Format(time.RFC3339))
+	builder.WriteString(" ")
+
+	level := strings.ToUpper(l.Level)
+	if len(level) > 4 {
+		level = level[0:4]
+	}
+
+	builder.WriteString(fmt.Sprintf("%-5s ", level))
+	builder.WriteString(strings.ReplaceAll(l.Message, "\n", " "))
+
+	if len(l.Tags) > 0 {
+		builder.WriteString("   ")
+	}
+
+	for _, t := range l.Tags {
+		builder.WriteString(" ")
+		builder.WriteString(t.Name)
+
+		if t.Value != "" {
+			builder.WriteString(" ")
+			builder.WriteString(t.Value)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,108 @@
+package dollop
+
+import (
+	"strings"
+	"time"
+
+	"github.com/elseano/dollop/internal/templating"
+)
+
+func getGroupAndTitle(config Config, line map[string]interface{}) (value string, title string, spec *GroupSpec) {
+	for _, spec := range config.Groups {
+		currentValue, valueErr := templating.ApplyTemplate(spec.valueTmpl, line)
+		currentTitle, titleErr := templating.ApplyTemplate(spec.titleTmpl, line)
+
+		if valueErr == nil && titleErr == nil && currentValue != "" && currentTitle != "" {
+			return currentValue, currentTitle, spec
+		}
+	}
+
+	return
+}
+
+func getStatus(config Config, line map[string]interface{}) (status string) {
+	for _, spec := range config.Statuses {
+		currentDisplay, displayErr := templating.ApplyTemplate(spec.displayTmpl, line)
+
+		if displayErr == nil && currentDisplay != "" {
+			return currentDisplay
+		}
+	}
+
+	return
+}
+
+// getTimestamp reads the line's timestamp, falling back to when it was read. Also returns true when
+// the line has a timestamp which couldn't be parsed.
+func getTimestamp(config Config, line map[string]interface{}, readAt time.Time) (time.Time, bool) {
+	text, ok := timestampText(config, line)
+	if !ok {
+		return inZone(config, readAt), false
+	}
+
+	if t, ok := parseTime(config, text); ok {
+		return inZone(config, t), false
+	}
+
+	return inZone(config, readAt), true
+}
+
+func parseTimestamp(config Config, line map[string]interface{}) (time.Time, bool) {
+	text, ok := timestampText(config, line)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, ok := parseTime(config, text)
+
+	return inZone(config, t), ok
+}
+
+func timestampText(config Config, line map[string]interface{}) (string, bool) {
+	text, err := templating.ApplyTemplate(config.timestampTmpl, line)
+	text = strings.TrimSpace(text)
+
+	return text, err == nil && text != ""
+}
+
+func getLevel(config Config, line map[string]interface{}) string {
+	level, err := templating.ApplyTemplate(config.levelTmpl, line)
+	if err == nil {
+		if renamed, ok := config.Levels[strings.ToLower(level)]; ok {
+			return renamed
+		}
+
+		return level
+	} else {
+		return "unknown"
+	}
+}
+
+func getTags(tags []*TagSpec, line map[string]interface{}) []Tag {
+	result := []Tag{}
+	tagsAlready := map[string]struct{}{}
+
+	for _, tagSpec := range tags {
+		key, err := templating.ApplyTemplate(tagSpec.keyTmpl, line)
+		if err != nil || key == "" {
+			continue
+		}
+
+		if _, ok := tagsAlready[key]; ok {
+			continue
+		}
+
+		if tagSpec.valueTmpl != nil {
+			value, err := templating.ApplyTemplate(tagSpec.valueTmpl, line)
+			if err == nil && value != "" {
+				result = append(result, Tag{Name: key, Value: value})
+				tagsAlready[key] = struct{}{}
+			}
+		} else {
+			result = append(result, Tag{Name: key})
+			tagsAlready[key] = struct{}{}
+		}
+	}
+
+	return result
+}
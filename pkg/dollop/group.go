@@ -0,0 +1,108 @@
+package dollop
+
+import (
+	"strings"
+	"time"
+)
+
+// Tag is a label shown against a line, with an optional value.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Line is a single log entry, with the fields the config extracted from it.
+type Line struct {
+	Level     string
+	Timestamp time.Time
+	Message   string
+	Data      map[string]interface{}
+	Tags      []Tag
+	Source    string
+	// Raw is the text the line was parsed from, including any continuation lines. It's empty for
+	// records which were added already parsed.
+	Raw string
+	// TimestampFailed is set when the line's timestamp couldn't be parsed, so Timestamp is when the
+	// line was read instead.
+	TimestampFailed bool
+
+	// seq is the order the line arrived in, so it can be reprocessed in the same order.
+	seq int
+	// record is set for lines added already parsed, which have no raw text to parse again. Blank
+	// lines also have an empty Raw, so it can't be used to tell.
+	record bool
+}
+
+// IsRecord returns true for lines which were added already parsed, rather than parsed from text.
+// Those have no Raw text, but blank lines don't either.
+func (l Line) IsRecord() bool {
+	return l.record
+}
+
+// Group collects the lines which share a group value, such as all the lines for one request.
+type Group struct {
+	Title string
+	// Description is the name of the GroupSpec which matched, or a description of a built in group.
+	Description string
+	// Spec is the GroupSpec which matched, or nil for the built in groups.
+	Spec  *GroupSpec
+	Value string
+	// Timestamp is the time of the most recent line.
+	Timestamp time.Time
+	Lines     []Line
+	// Sources lists the inputs the group's lines were read from, in the order they first appeared.
+	Sources []string
+
+	// catchAll groups collect lines which didn't match a GroupSpec. They have their oldest lines
+	// trimmed by retention limits, rather than being evicted.
+	catchAll bool
+	errors   int
+}
+
+func (g *Group) addLine(line Line) {
+	g.Lines = append(g.Lines, line)
+
+	if isErrorLevel(line.Level) {
+		g.errors++
+	}
+
+	if line.Source == "" {
+		return
+	}
+
+	for _, s := range g.Sources {
+		if s == line.Source {
+			return
+		}
+	}
+
+	g.Sources = append(g.Sources, line.Source)
+}
+
+// HasErrors returns true if any of the group's lines are at an error level or worse.
+func (g *Group) HasErrors() bool {
+	return g.errors > 0
+}
+
+func isErrorLevel(level string) bool {
+	level = strings.ToLower(level)
+
+	for _, prefix := range []string{"err", "fatal", "panic", "crit", "alert", "emerg"} {
+		if strings.HasPrefix(level, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TallyLevels counts the lines at each level.
+func (g *Group) TallyLevels() map[string]int {
+	result := map[string]int{}
+
+	for _, line := range g.Lines {
+		result[line.Level]++
+	}
+
+	return result
+}
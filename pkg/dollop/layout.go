@@ -0,0 +1,116 @@
+package dollop
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimestampFormats are the named timestamp formats. Any other format is a Go or strftime layout.
+var TimestampFormats = []string{"auto", "rfc3339", "rfc3339nano", "unix", "unix_ms", "unix_ns"}
+
+// strftimeDirectives are the Go layout for each strftime directive.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'f': "000000",
+	'L': "000",
+	'N': "000000000",
+	'z': "-0700",
+	'Z': "MST",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+	'D': "01/02/06",
+	'%': "%",
+}
+
+// timestampLayout returns the Go layout for a format, which is empty for the named formats.
+func timestampLayout(format string) string {
+	if isNamedFormat(format) {
+		return ""
+	}
+
+	if !strings.Contains(format, "%") {
+		return format
+	}
+
+	layout, _ := strftimeLayout(format)
+
+	return layout
+}
+
+// strftimeLayout converts a strftime format, such as "%Y-%m-%d %H:%M:%S", to a Go layout.
+func strftimeLayout(format string) (string, error) {
+	b := strings.Builder{}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		if i+1 == len(format) {
+			return "", fmt.Errorf("%q ends with %%", format)
+		}
+
+		i++
+
+		layout, ok := strftimeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("%%%c in %q isn't supported", format[i], format)
+		}
+
+		b.WriteString(layout)
+	}
+
+	return b.String(), nil
+}
+
+func isNamedFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+
+	for _, named := range TimestampFormats {
+		if format == named {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkTimestampFormat(format string) error {
+	if isNamedFormat(format) {
+		return nil
+	}
+
+	layout := format
+	if strings.Contains(format, "%") {
+		var err error
+		if layout, err = strftimeLayout(format); err != nil {
+			return err
+		}
+	}
+
+	// A layout without any of the reference time's parts would never match anything.
+	if time.Unix(0, 0).UTC().Format(layout) == layout {
+		return fmt.Errorf("must be one of %s, or a Go or strftime layout, not %q", strings.Join(TimestampFormats, ", "), format)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package dollop
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrftimeLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		err    string
+	}{
+		{format: "%Y-%m-%d %H:%M:%S", want: "2006-01-02 15:04:05"},
+		{format: "%F %T.%L %z", want: "2006-01-02 15:04:05.000 -0700"},
+		{format: "%y%m%d-%I:%M %p", want: "060102-03:04 PM"},
+		{format: "%a, %e %B %Y %Z", want: "Mon, _2 January 2006 MST"},
+		{format: "%A %j %D", want: "Monday 002 01/02/06"},
+		{format: "%S.%f", want: "05.000000"},
+		{format: "%S.%N", want: "05.000000000"},
+		{format: "%b %h", want: "Jan Jan"},
+		{format: "at %H%%", want: "at 15%"},
+		{format: "%%Y", want: "%Y"},
+		{format: "no directives", want: "no directives"},
+
+		{format: "%Y-%m-%d %k", err: `%k in "%Y-%m-%d %k" isn't supported`},
+		{format: "%s", err: `%s in "%s" isn't supported`},
+		{format: "%H:%M%", err: `"%H:%M%" ends with %`},
+	}
+
+	for _, test := range tests {
+		got, err := strftimeLayout(test.format)
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q: error = %v, want %s", test.format, err, test.err)
+			}
+
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("%q = %q %v, want %q", test.format, got, err, test.want)
+		}
+	}
+}
+
+func TestTimestampLayout(t *testing.T) {
+	tests := map[string]string{
+		"":                    "",
+		"auto":                "",
+		"unix_ms":             "",
+		"2006-01-02 15:04:05": "2006-01-02 15:04:05",
+		"%Y-%m-%dT%H:%M:%S":   "2006-01-02T15:04:05",
+		"%Y %k":               "",
+	}
+
+	for format, want := range tests {
+		if got := timestampLayout(format); got != want {
+			t.Errorf("%q = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestCheckTimestampFormat(t *testing.T) {
+	tests := map[string]string{
+		"auto":                "",
+		"unix_ns":             "",
+		"2006-01-02 15:04:05": "",
+		"%Y-%m-%d":            "",
+		"%Y-%q":               "%q in",
+		"%":                   "\"%\" ends with %",
+		"epoch":               "must be one of auto, rfc3339, rfc3339nano, unix, unix_ms, unix_ns",
+		"%%":                  "must be one of",
+	}
+
+	for format, want := range tests {
+		err := checkTimestampFormat(format)
+
+		if want == "" {
+			if err != nil {
+				t.Errorf("%q: %s", format, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: error = %v, want %s", format, err, want)
+		}
+	}
+}
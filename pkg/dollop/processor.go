@@ -0,0 +1,441 @@
+// Package dollop parses, groups and tags log lines according to a Config, independently of any UI.
+package dollop
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elseano/dollop/internal/parser"
+	"github.com/elseano/dollop/internal/templating"
+)
+
+// Result describes what happened to a processed line.
+type Result struct {
+	// Group is the group the line was added to, or the group of the entry it was appended to.
+	Group *Group
+	// Status is the status the line should display, if any of the config's statuses matched.
+	Status string
+	// Continuation is true when the line was appended to the group's latest entry from the same
+	// source, rather than being added as a line of its own.
+	Continuation bool
+}
+
+// continuationTarget is the most recent parsed line from a source, which continuation lines are appended to.
+type continuationTarget struct {
+	group *Group
+	index int
+	tags  []*TagSpec
+}
+
+// Processor turns raw lines into groups. It is not safe for concurrent use.
+type Processor struct {
+	config Config
+
+	groups     map[string]*Group
+	textGroup  *Group
+	errorGroup *Group
+
+	order      []*Group
+	unsorted   map[*Group]struct{}
+	changed    map[*Group]struct{}
+	trimmed    map[*Group]int
+	totalLines int
+	evicted    int
+	// lastSeq numbers the lines as they arrive.
+	lastSeq int
+
+	continuationTargets map[string]continuationTarget
+}
+
+// NewProcessor creates a Processor for a config, returning an error if the config isn't valid. See
+// Config.Check for every problem with a config.
+func NewProcessor(config Config) (*Processor, error) {
+	if err := config.prepare(); err != nil {
+		return nil, err
+	}
+
+	return newProcessor(config), nil
+}
+
+func newProcessor(config Config) *Processor {
+	p := &Processor{
+		config: config,
+		groups: map[string]*Group{},
+		errorGroup: &Group{
+			Title:       "Parse Failures",
+			Description: "Errors",
+			Timestamp:   time.Now(),
+		},
+		textGroup: &Group{
+			Title:       "Text",
+			Description: "Not JSON",
+			Timestamp:   time.Now(),
+		},
+		unsorted:            map[*Group]struct{}{},
+		changed:             map[*Group]struct{}{},
+		trimmed:             map[*Group]int{},
+		continuationTargets: map[string]continuationTarget{},
+	}
+
+	p.errorGroup.catchAll = true
+	p.textGroup.catchAll = true
+
+	p.markChanged(p.errorGroup)
+	p.markChanged(p.textGroup)
+	p.Reorder()
+	p.Changed()
+
+	return p
+}
+
+// Config returns the config the Processor was created with.
+func (p *Processor) Config() Config {
+	return p.config
+}
+
+// Process parses a line of raw input read from the named source, and adds it to its group.
+// Lines which can't be parsed go into the "Text" or "Parse Failures" groups.
+func (p *Processor) Process(source string, text string) Result {
+	return p.ProcessAt(source, text, time.Now())
+}
+
+// ProcessAt processes a line which was read at an earlier time, such as from a recorded session.
+// Lines without a timestamp of their own are given the time they were read.
+func (p *Processor) ProcessAt(source string, text string, at time.Time) Result {
+	if target := p.appendContinuation(source, text); target != nil {
+		p.markChanged(target)
+		return Result{Group: target, Continuation: true}
+	}
+
+	raw := strings.TrimRight(text, "\r\n")
+	res, err := p.parseLine(text)
+
+	if err != nil {
+		// Continuation lines only follow on from a parsed entry.
+		delete(p.continuationTargets, source)
+	}
+
+	if errors.Is(err, parser.ErrUnrecognised) {
+		return p.addUnparsed(p.textGroup, Line{
+			Message:   raw,
+			Source:    source,
+			Timestamp: inZone(p.config, at),
+			Raw:       raw,
+		})
+	}
+
+	if err != nil {
+		return p.addUnparsed(p.errorGroup, Line{
+			Message:   fmt.Sprintf("Error loading '%s': %s", text, err.Error()),
+			Data:      res,
+			Source:    source,
+			Timestamp: inZone(p.config, at),
+			Raw:       raw,
+		})
+	}
+
+	return p.processRecord(source, &raw, res, at)
+}
+
+func (p *Processor) addUnparsed(group *Group, line Line) Result {
+	p.lastSeq++
+	line.seq = p.lastSeq
+
+	group.addLine(line)
+	p.totalLines++
+	p.markChanged(group)
+
+	return Result{Group: group}
+}
+
+func (p *Processor) markChanged(group *Group) {
+	p.unsorted[group] = struct{}{}
+	p.changed[group] = struct{}{}
+}
+
+// ProcessRecord adds an already parsed record to its group.
+func (p *Processor) ProcessRecord(source string, res map[string]interface{}) Result {
+	return p.processRecord(source, nil, res, time.Now())
+}
+
+// processRecord adds a parsed line to its group. raw is the text it was parsed from, or nil when it
+// was added already parsed.
+func (p *Processor) processRecord(source string, raw *string, res map[string]interface{}, at time.Time) Result {
+	// Continuation lines are added to the record's fields.
+	if res == nil {
+		res = map[string]interface{}{}
+	}
+
+	groupValue, groupTitle, groupSpec := getGroupAndTitle(p.config, res)
+	timestamp, timestampFailed := getTimestamp(p.config, res, at)
+	status := getStatus(p.config, res)
+
+	var specName string
+
+	if groupSpec == nil {
+		groupValue = "nogroup"
+		groupTitle = "No Group"
+		specName = "Ungrouped"
+	} else {
+		specName = groupSpec.Name
+	}
+
+	group, exists := p.groups[groupValue]
+	if !exists {
+		group = &Group{
+			Title:       groupTitle,
+			Description: specName,
+			Spec:        groupSpec,
+			Value:       groupValue,
+			catchAll:    groupSpec == nil,
+		}
+		p.groups[groupValue] = group
+	}
+
+	message, err := templating.ApplyTemplate(p.config.messageTmpl, res)
+	if err != nil {
+		message = fmt.Sprintf("Field %s not found in data: %s", p.config.MessageField, err.Error())
+	}
+
+	group.Timestamp = timestamp
+
+	line := Line{
+		Message:   message,
+		Data:      res,
+		Level:     getLevel(p.config, res),
+		Timestamp: timestamp,
+		Source:    source,
+
+		TimestampFailed: timestampFailed,
+	}
+
+	if raw != nil {
+		line.Raw = *raw
+	} else {
+		line.record = true
+	}
+
+	tagSpecs := p.config.Tags
+	if groupSpec != nil {
+		// Limit the capacity so the group tags are always appended onto a copy.
+		tagSpecs = append(tagSpecs[:len(tagSpecs):len(tagSpecs)], groupSpec.Tags...)
+	}
+
+	line.Tags = getTags(tagSpecs, res)
+
+	p.lastSeq++
+	line.seq = p.lastSeq
+
+	group.addLine(line)
+	p.totalLines++
+	p.markChanged(group)
+	p.continuationTargets[source] = continuationTarget{group: group, index: len(group.Lines) - 1, tags: tagSpecs}
+
+	return Result{Group: group, Status: status}
+}
+
+// Reconfigure switches to a new config, then reprocesses the lines which are still retained in the
+// order they arrived, so they're grouped and tagged by the new rules. The groups are all replaced,
+// along with their lines, so any held from before must be dropped. When the config isn't valid, the
+// error is returned and nothing changes.
+func (p *Processor) Reconfigure(config Config) error {
+	if err := config.prepare(); err != nil {
+		return err
+	}
+
+	// Groups which haven't been reordered yet aren't in the order, so they're found by value.
+	lines := []Line{}
+	lines = append(lines, p.textGroup.Lines...)
+	lines = append(lines, p.errorGroup.Lines...)
+
+	for _, g := range p.groups {
+		lines = append(lines, g.Lines...)
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return lines[i].seq < lines[j].seq })
+
+	evicted := p.evicted
+	*p = *newProcessor(config)
+	p.evicted = evicted
+
+	for _, line := range lines {
+		if line.record {
+			p.processRecord(line.Source, nil, line.Data, line.Timestamp)
+			continue
+		}
+
+		// The raw text includes any continuation lines, which may or may not continue the
+		// entry under the new config.
+		for _, text := range strings.Split(line.Raw, "\n") {
+			p.ProcessAt(line.Source, text, line.Timestamp)
+		}
+	}
+
+	return nil
+}
+
+// Reset drops every group and line, as if the Processor had just been created. Any groups held from
+// before must be dropped too.
+func (p *Processor) Reset() {
+	*p = *newProcessor(p.config)
+}
+
+// Timestamp parses a line of raw input without adding it, returning its timestamp. It returns false
+// when the line can't be parsed, or doesn't have a timestamp.
+func (p *Processor) Timestamp(text string) (time.Time, bool) {
+	res, err := p.parseLine(text)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return parseTimestamp(p.config, res)
+}
+
+// appendContinuation adds the line onto the previous entry from the same source if it matches a
+// continuation rule, such as being part of a stack trace. The entry's tags are updated to match.
+// Returns the group the entry belongs to, or nil if the line isn't a continuation.
+func (p *Processor) appendContinuation(source string, text string) *Group {
+	target, ok := p.continuationTargets[source]
+	if !ok {
+		return nil
+	}
+
+	text = strings.TrimRight(text, "\r\n")
+
+	for _, spec := range p.config.Continuations {
+		if !spec.matchRegexp.MatchString(text) {
+			continue
+		}
+
+		line := &target.group.Lines[target.index]
+
+		if existing, ok := line.Data[spec.Field].(string); ok && existing != "" {
+			line.Data[spec.Field] = existing + "\n" + text
+		} else {
+			line.Data[spec.Field] = text
+		}
+
+		line.Tags = getTags(target.tags, line.Data)
+
+		if !line.record {
+			line.Raw += "\n" + text
+		}
+
+		return target.group
+	}
+
+	return nil
+}
+
+// parseLine tries each format in turn, returning the fields from the first one which parses the line.
+// If none do, the first error from a format which recognised the line is returned, or ErrUnrecognised.
+func (p *Processor) parseLine(line string) (map[string]interface{}, error) {
+	var firstErr error
+
+	for _, format := range p.config.Formats {
+		text := line
+		var captured map[string]interface{}
+
+		if format.matchRegexp != nil {
+			loc := format.matchRegexp.FindStringSubmatchIndex(line)
+			if loc == nil {
+				continue
+			}
+
+			// The match needn't be anchored, so only the matched text is removed.
+			captured = parser.Captures(format.matchRegexp, line, loc)
+			text = line[:loc[0]] + line[loc[1]:]
+		}
+
+		res, err := format.parse(text)
+		if err != nil {
+			if firstErr == nil && !errors.Is(err, parser.ErrUnrecognised) {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		for k, v := range captured {
+			if _, exists := res[k]; !exists {
+				res[k] = v
+			}
+		}
+
+		return res, nil
+	}
+
+	if firstErr == nil {
+		firstErr = parser.ErrUnrecognised
+	}
+
+	return nil, firstErr
+}
+
+// Changed returns the groups which have had lines added, updated or removed since the last call.
+func (p *Processor) Changed() []*Group {
+	result := make([]*Group, 0, len(p.changed))
+	for g := range p.changed {
+		result = append(result, g)
+	}
+
+	p.changed = map[*Group]struct{}{}
+
+	return result
+}
+
+// Reorder moves the groups which have changed since the last call into position, newest first.
+// The unchanged groups are already in order, so each changed group is inserted with a binary search
+// rather than sorting everything. Returns true if the order of Groups changed.
+func (p *Processor) Reorder() bool {
+	if len(p.unsorted) == 0 {
+		return false
+	}
+
+	order := make([]*Group, 0, len(p.order)+len(p.unsorted))
+
+	for _, g := range p.order {
+		if _, ok := p.unsorted[g]; !ok {
+			order = append(order, g)
+		}
+	}
+
+	for group := range p.unsorted {
+		to := sort.Search(len(order), func(i int) bool {
+			return groupBefore(group, order[i])
+		})
+
+		order = append(order, nil)
+		copy(order[to+1:], order[to:])
+		order[to] = group
+	}
+
+	orderChanged := len(order) != len(p.order)
+	for i := 0; !orderChanged && i < len(order); i++ {
+		orderChanged = order[i] != p.order[i]
+	}
+
+	p.order = order
+	p.unsorted = map[*Group]struct{}{}
+
+	return orderChanged
+}
+
+// Groups returns every group, newest first, as of the last call to Reorder. The slice must not be modified.
+func (p *Processor) Groups() []*Group {
+	return p.order
+}
+
+// groupBefore orders groups newest first, then by title.
+func groupBefore(a *Group, b *Group) bool {
+	diff := a.Timestamp.Sub(b.Timestamp)
+
+	if diff == 0 {
+		return strings.Compare(a.Title, b.Title) > 0
+	}
+
+	return diff > 0
+}
@@ -0,0 +1,438 @@
+package dollop
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func testConfig() Config {
+	return Config{
+		MessageField:   "msg",
+		LevelField:     "level",
+		TimestampField: "time",
+		Groups: []*GroupSpec{
+			{ValueField: "request_id", TitleField: "msg", Name: "Request"},
+		},
+	}
+}
+
+func newTestProcessor(t *testing.T, config Config) *Processor {
+	t.Helper()
+
+	p, err := NewProcessor(config)
+	if err != nil {
+		t.Fatalf("NewProcessor: %s", err)
+	}
+
+	return p
+}
+
+// entry writes a JSON line for a request, logged the given number of seconds after start.
+func entry(request string, seconds int, level string, msg string) string {
+	at := start.Add(time.Duration(seconds) * time.Second).Format(time.RFC3339)
+	return fmt.Sprintf(`{"time":%q,"level":%q,"msg":%q,"request_id":%q}`, at, level, msg, request)
+}
+
+// specGroups returns the groups which matched a GroupSpec, without the built in Text and Parse Failures
+// groups, which are timestamped when the Processor is created.
+func specGroups(p *Processor) []*Group {
+	result := []*Group{}
+	for _, g := range p.Groups() {
+		if !g.catchAll {
+			result = append(result, g)
+		}
+	}
+
+	return result
+}
+
+func titles(groups []*Group) []string {
+	result := make([]string, len(groups))
+	for i, g := range groups {
+		result[i] = g.Title
+	}
+
+	return result
+}
+
+func assertTitles(t *testing.T, groups []*Group, want ...string) {
+	t.Helper()
+
+	got := titles(groups)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+}
+
+func TestNewProcessorRejectsInvalidConfig(t *testing.T) {
+	config := testConfig()
+	config.Groups[0].TitleField = "{{ .msg"
+
+	if _, err := NewProcessor(config); err == nil {
+		t.Fatal("expected an error for a template which doesn't parse")
+	}
+}
+
+func TestProcessGroupsLines(t *testing.T) {
+	p := newTestProcessor(t, testConfig())
+
+	first := p.ProcessAt("app", entry("a", 0, "info", "GET /a"), start)
+	p.ProcessAt("app", entry("b", 1, "info", "GET /b"), start)
+	second := p.ProcessAt("app", entry("a", 2, "error", "failed"), start)
+
+	if first.Group != second.Group {
+		t.Fatal("lines with the same request_id were put in different groups")
+	}
+
+	group := first.Group
+	if group.Title != "GET /a" || group.Description != "Request" || group.Value != "a" {
+		t.Errorf("group = %q %q %q, want GET /a Request a", group.Title, group.Description, group.Value)
+	}
+
+	if len(group.Lines) != 2 {
+		t.Fatalf("group has %d lines, want 2", len(group.Lines))
+	}
+
+	line := group.Lines[1]
+	if line.Message != "failed" || line.Level != "error" || line.Source != "app" {
+		t.Errorf("line = %q %q %q, want failed error app", line.Message, line.Level, line.Source)
+	}
+
+	if !line.Timestamp.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("timestamp = %s, want the line's own time", line.Timestamp)
+	}
+
+	if !group.HasErrors() {
+		t.Error("group with an error line doesn't report errors")
+	}
+}
+
+func TestProcessUngroupedAndUnparsed(t *testing.T) {
+	p := newTestProcessor(t, testConfig())
+
+	ungrouped := p.ProcessAt("app", `{"msg":"no request","level":"info"}`, start)
+	if ungrouped.Group.Title != "No Group" {
+		t.Errorf("line without a request_id went to %q, want No Group", ungrouped.Group.Title)
+	}
+
+	text := p.ProcessAt("app", "just some text", start)
+	if text.Group != p.textGroup {
+		t.Errorf("plain text went to %q, want Text", text.Group.Title)
+	}
+
+	if got := text.Group.Lines[0]; got.Message != "just some text" || !got.Timestamp.Equal(start) {
+		t.Errorf("text line = %q at %s, want the text at the time it was read", got.Message, got.Timestamp)
+	}
+
+	broken := p.ProcessAt("app", `{"msg": "broken`, start)
+	if broken.Group != p.errorGroup {
+		t.Errorf("broken JSON went to %q, want Parse Failures", broken.Group.Title)
+	}
+}
+
+func TestProcessStripsMatch(t *testing.T) {
+	config := testConfig()
+	config.Formats = []*FormatSpec{{Type: "logfmt", Match: `\[(?P<container>\w+)\] `}}
+	p := newTestProcessor(t, config)
+
+	// Only the matched text is removed, wherever it is in the line.
+	res := p.ProcessAt("app", `level=warn [web] msg=hello request_id=a`, start)
+
+	line := res.Group.Lines[len(res.Group.Lines)-1]
+	if line.Message != "hello" || line.Level != "warn" || line.Data["container"] != "web" {
+		t.Errorf("line = %q %q with container %v, want hello warn from web", line.Message, line.Level, line.Data["container"])
+	}
+}
+
+func TestParseLineRemovesOnlyMatch(t *testing.T) {
+	tests := []struct {
+		match string
+		line  string
+		want  map[string]interface{}
+	}{
+		{
+			// The usual case, a prefix added by a log shipper.
+			match: `^(?P<container>\w+) \| `,
+			line:  `web | level=info msg=started`,
+			want:  map[string]interface{}{"container": "web", "level": "info", "msg": "started"},
+		},
+		{
+			// Text before the match is parsed too, rather than being dropped with it.
+			match: ` \[(?P<container>\w+)\]`,
+			line:  `level=warn [web] msg=slow`,
+			want:  map[string]interface{}{"container": "web", "level": "warn", "msg": "slow"},
+		},
+		{
+			match: ` #(?P<trace>\d+)$`,
+			line:  `level=error msg=failed #1234`,
+			want:  map[string]interface{}{"trace": 1234.0, "level": "error", "msg": "failed"},
+		},
+		{
+			// Parsed fields win over captures with the same name.
+			match: `^(?P<level>\w+): `,
+			line:  `WARN: level=warning msg=disk`,
+			want:  map[string]interface{}{"level": "warning", "msg": "disk"},
+		},
+	}
+
+	for _, test := range tests {
+		config := testConfig()
+		config.Formats = []*FormatSpec{{Type: "logfmt", Match: test.match}}
+		p := newTestProcessor(t, config)
+
+		got, err := p.parseLine(test.line)
+		if err != nil {
+			t.Errorf("%q: %s", test.line, err)
+			continue
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%q = %v, want %v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestProcessContinuations(t *testing.T) {
+	config := testConfig()
+	config.Continuations = []*ContinuationSpec{{Match: `^\s`}}
+	p := newTestProcessor(t, config)
+
+	p.ProcessAt("app", entry("a", 0, "error", "panic"), start)
+	other := p.ProcessAt("worker", entry("b", 1, "info", "working"), start)
+
+	first := p.ProcessAt("app", "  at main.go:10", start)
+	second := p.ProcessAt("app", "  at main.go:20\n", start)
+
+	if !first.Continuation || !second.Continuation {
+		t.Fatal("indented lines weren't treated as continuations")
+	}
+
+	line := first.Group.Lines[0]
+	if got := line.Data["stacktrace"]; got != "  at main.go:10\n  at main.go:20" {
+		t.Errorf("stacktrace = %q", got)
+	}
+
+	if want := entry("a", 0, "error", "panic") + "\n  at main.go:10\n  at main.go:20"; line.Raw != want {
+		t.Errorf("raw = %q, want %q", line.Raw, want)
+	}
+
+	if len(other.Group.Lines[0].Data) != 4 {
+		t.Error("continuation was appended to the entry from another source")
+	}
+
+	// Continuations only follow a parsed entry, so one after plain text is a line of its own.
+	p.ProcessAt("app", "plain text", start)
+	if res := p.ProcessAt("app", "  indented", start); res.Continuation {
+		t.Error("continuation was appended across an unparsed line")
+	}
+}
+
+func TestProcessContinuationOfEmptyRecord(t *testing.T) {
+	config := testConfig()
+	config.Continuations = []*ContinuationSpec{{Match: `^\s`}}
+	p := newTestProcessor(t, config)
+
+	p.ProcessRecord("app", nil)
+
+	res := p.ProcessAt("app", "  at main.go:10", start)
+	if !res.Continuation {
+		t.Fatal("indented line wasn't appended to the record")
+	}
+
+	if got := res.Group.Lines[0].Data["stacktrace"]; got != "  at main.go:10" {
+		t.Errorf("stacktrace = %q", got)
+	}
+}
+
+func TestProcessRecordsAndBlankLines(t *testing.T) {
+	p := newTestProcessor(t, testConfig())
+
+	blank := p.ProcessAt("app", "", start)
+	record := p.ProcessRecord("app", map[string]interface{}{"msg": "parsed", "request_id": "a"})
+
+	// Neither has any raw text, but only the record was added already parsed.
+	if line := blank.Group.Lines[0]; line.Raw != "" || line.IsRecord() {
+		t.Errorf("blank line = %q, record %t, want an empty line which isn't a record", line.Raw, line.IsRecord())
+	}
+
+	if line := record.Group.Lines[0]; line.Raw != "" || !line.IsRecord() {
+		t.Errorf("record = %q, record %t, want a record without raw text", line.Raw, line.IsRecord())
+	}
+}
+
+func TestReorder(t *testing.T) {
+	p := newTestProcessor(t, testConfig())
+
+	p.ProcessAt("app", entry("a", 0, "info", "A"), start)
+	p.ProcessAt("app", entry("b", 10, "info", "B"), start)
+
+	if !p.Reorder() {
+		t.Error("Reorder didn't report new groups")
+	}
+
+	if p.Reorder() {
+		t.Error("Reorder reported a change with nothing new")
+	}
+
+	assertTitles(t, specGroups(p), "B", "A")
+	p.Changed()
+
+	// A new line moves its group to the front.
+	p.ProcessAt("app", entry("a", 20, "info", "A again"), start)
+
+	if !p.Reorder() {
+		t.Error("Reorder didn't report the group moving")
+	}
+
+	assertTitles(t, specGroups(p), "A", "B")
+
+	changed := p.Changed()
+	if len(changed) != 1 || changed[0].Title != "A" {
+		t.Errorf("changed = %q, want A", titles(changed))
+	}
+
+	if len(p.Changed()) != 0 {
+		t.Error("Changed didn't reset")
+	}
+}
+
+func TestEvict(t *testing.T) {
+	config := testConfig()
+	// The built in Text and Parse Failures groups don't count towards MaxGroups.
+	config.MaxGroups = 2
+	config.PinErrors = true
+	p := newTestProcessor(t, config)
+
+	p.ProcessAt("app", entry("a", 0, "error", "A"), start)
+	p.ProcessAt("app", entry("b", 1, "info", "B"), start)
+	p.ProcessAt("app", entry("c", 2, "info", "C"), start)
+	p.ProcessAt("app", entry("d", 3, "info", "D"), start)
+	p.Reorder()
+
+	evicted := p.Evict(start)
+
+	// The oldest groups are evicted first, but A is pinned by its error.
+	assertTitles(t, evicted, "C", "B")
+	assertTitles(t, specGroups(p), "D", "A")
+
+	if p.EvictedCount() != 2 {
+		t.Errorf("EvictedCount = %d, want 2", p.EvictedCount())
+	}
+
+	// An evicted group's value starts a new group.
+	res := p.ProcessAt("app", entry("b", 4, "info", "B again"), start)
+	if len(res.Group.Lines) != 1 {
+		t.Errorf("evicted group was reused, with %d lines", len(res.Group.Lines))
+	}
+}
+
+func TestEvictTrimsCatchAllGroups(t *testing.T) {
+	config := testConfig()
+	config.MaxLines = 2
+	p := newTestProcessor(t, config)
+
+	p.ProcessAt("app", "one", start)
+	p.ProcessAt("app", "two", start)
+	p.ProcessAt("app", "three", start)
+	p.Reorder()
+
+	if evicted := p.Evict(start); len(evicted) != 0 {
+		t.Errorf("evicted %q, want the Text group trimmed instead", titles(evicted))
+	}
+
+	if lines := p.textGroup.Lines; len(lines) != 2 || lines[0].Message != "two" {
+		t.Errorf("Text has %d lines, want the newest 2", len(lines))
+	}
+
+	if trimmed := p.Trimmed(); trimmed[p.textGroup] != 1 {
+		t.Errorf("Trimmed = %d for Text, want 1", trimmed[p.textGroup])
+	}
+
+	if len(p.Trimmed()) != 0 {
+		t.Error("Trimmed didn't reset")
+	}
+}
+
+func TestEvictMaxAge(t *testing.T) {
+	config := testConfig()
+	config.MaxAge = time.Minute
+	p := newTestProcessor(t, config)
+
+	p.ProcessAt("app", entry("a", 0, "info", "A"), start)
+	p.ProcessAt("app", entry("b", 90, "info", "B"), start)
+	p.Reorder()
+
+	assertTitles(t, p.Evict(start.Add(2*time.Minute)), "A")
+}
+
+func TestReconfigure(t *testing.T) {
+	config := testConfig()
+	config.Continuations = []*ContinuationSpec{{Match: `^\s`}}
+	p := newTestProcessor(t, config)
+
+	p.ProcessAt("app", `{"time":"2022-06-01T10:00:00Z","msg":"one","request_id":"a","user":"x"}`, start)
+	p.ProcessAt("app", `{"time":"2022-06-01T10:00:01Z","msg":"two","request_id":"b","user":"x"}`, start)
+	p.ProcessAt("app", "  continued", start)
+	p.Reorder()
+
+	regrouped := testConfig()
+	regrouped.Groups = []*GroupSpec{{ValueField: "user", TitleField: "user", Name: "User"}}
+	regrouped.Continuations = []*ContinuationSpec{{Match: `^\s`}}
+
+	if err := p.Reconfigure(regrouped); err != nil {
+		t.Fatalf("Reconfigure: %s", err)
+	}
+
+	p.Reorder()
+
+	groups := specGroups(p)
+	if len(groups) != 1 || groups[0].Title != "x" || groups[0].Description != "User" {
+		t.Fatalf("groups = %q, want x", titles(groups))
+	}
+
+	lines := groups[0].Lines
+	if len(lines) != 2 || lines[0].Message != "one" || lines[1].Message != "two" {
+		t.Fatalf("lines weren't reprocessed in the order they arrived")
+	}
+
+	if lines[1].Data["stacktrace"] != "  continued" {
+		t.Errorf("continuation wasn't reapplied, stacktrace = %q", lines[1].Data["stacktrace"])
+	}
+
+	invalid := testConfig()
+	invalid.MessageField = ""
+
+	if err := p.Reconfigure(invalid); err == nil {
+		t.Error("Reconfigure accepted an invalid config")
+	}
+
+	if p.Config().Groups[0].Name != "User" {
+		t.Error("an invalid config replaced the current one")
+	}
+}
+
+func TestReconfigureKeepsBlankLinesAndRecords(t *testing.T) {
+	p := newTestProcessor(t, testConfig())
+
+	p.ProcessAt("app", "", start)
+	p.ProcessRecord("app", map[string]interface{}{"msg": "parsed", "request_id": "a"})
+
+	if err := p.Reconfigure(testConfig()); err != nil {
+		t.Fatalf("Reconfigure: %s", err)
+	}
+
+	if lines := p.textGroup.Lines; len(lines) != 1 || lines[0].Message != "" {
+		t.Errorf("blank line wasn't reprocessed as text, Text has %d lines", len(lines))
+	}
+
+	if group := p.groups["a"]; group == nil || group.Lines[0].Message != "parsed" || !group.Lines[0].IsRecord() {
+		t.Error("record added already parsed wasn't regrouped")
+	}
+
+	if p.textGroup.Lines[0].IsRecord() {
+		t.Error("blank line was taken for a record")
+	}
+}
@@ -0,0 +1,543 @@
+package dollop
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search expression, which lines can be matched against.
+//
+// A query is made of terms, combined with "and", "or" and "not" (or &&, || and !), and grouped
+// with parentheses. Terms next to each other must both match. Each term is one of:
+//
+//	field=value     compare a field, such as level=error or status>=500. Also != > >= < <=
+//	field~pattern   match a field against a regular expression
+//	word            the message contains the word, ignoring case. Quote to include spaces
+//	/pattern/       the message matches a regular expression
+//
+// Patterns can also be written between slashes after ~, as in path~/^\/api/. Anywhere else a slash
+// is part of the word, so path=/api/users compares the path. ! only negates at the start of a term.
+//
+// Fields are looked up in the line's data, using dots for nested values, then in its tags. The
+// message is also available as msg, along with source and time. level is the line's level after
+// renaming by the config's levels. Values which look like
+// numbers are compared numerically, and durations such as 1s or 250ms are compared as durations,
+// with plain numbers taken as seconds.
+type Query struct {
+	text string
+	root queryNode
+}
+
+type queryNode interface {
+	match(line Line) bool
+}
+
+// ParseQuery parses a search expression.
+func ParseQuery(text string) (*Query, error) {
+	tokens, err := tokenizeQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &queryParser{tokens: tokens}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	return &Query{text: text, root: root}, nil
+}
+
+// String returns the query as it was written.
+func (q *Query) String() string {
+	return q.text
+}
+
+// Match returns true if the line matches the query.
+func (q *Query) Match(line Line) bool {
+	return q.root.match(line)
+}
+
+// CountMatches returns the number of the group's lines which match the query.
+func (q *Query) CountMatches(g *Group) int {
+	count := 0
+
+	for _, line := range g.Lines {
+		if q.Match(line) {
+			count++
+		}
+	}
+
+	return count
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenRegex
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+}
+
+var queryOperators = []string{">=", "<=", "!=", "&&", "||", "=", ">", "<", "~", "!"}
+
+func tokenizeQuery(text string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, text: "("})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, text: ")"})
+			i++
+
+		case r == '"':
+			end := closingDelimiter(runes, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c", r)
+			}
+
+			value := strings.ReplaceAll(string(runes[i+1:end]), `\"`, `"`)
+			tokens = append(tokens, queryToken{kind: tokenQuoted, text: value})
+			i = end + 1
+
+		case r == '/' && (afterOperator(tokens, "~") || startsTerm(tokens) && isPatternAt(runes, i)):
+			// A pattern is written between slashes after ~, or as a term of its own. Anywhere else,
+			// such as path=/api/users, a slash is part of the word.
+			end := closingDelimiter(runes, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c", r)
+			}
+
+			value := strings.ReplaceAll(string(runes[i+1:end]), `\/`, `/`)
+			tokens = append(tokens, queryToken{kind: tokenRegex, text: value})
+			i = end + 1
+
+		default:
+			if op := operatorAt(runes, i); op != "" && (op != "!" || startsTerm(tokens)) {
+				tokens = append(tokens, queryToken{kind: tokenOperator, text: op})
+				i += len(op)
+				continue
+			}
+
+			// ! on its own only negates at the start of a term, so it can end a word like hello!.
+			end := i + 1
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				if op := operatorAt(runes, end); op != "" && op != "!" {
+					break
+				}
+
+				end++
+			}
+
+			tokens = append(tokens, queryToken{kind: tokenWord, text: string(runes[i:end])})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// closingDelimiter returns the index of the delimiter closing the quote or pattern starting at i,
+// skipping escaped characters, or -1 if it isn't closed.
+func closingDelimiter(runes []rune, i int) int {
+	for end := i + 1; end < len(runes); end++ {
+		switch runes[end] {
+		case '\\':
+			end++
+		case runes[i]:
+			return end
+		}
+	}
+
+	return -1
+}
+
+// startsTerm returns true when the next token begins a term, rather than being the value of a comparison.
+func startsTerm(tokens []queryToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+
+	last := tokens[len(tokens)-1]
+
+	return last.kind != tokenOperator || !isComparison(last.text)
+}
+
+func afterOperator(tokens []queryToken, op string) bool {
+	return len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenOperator && tokens[len(tokens)-1].text == op
+}
+
+// isPatternAt returns true if the slash at i begins a /pattern/ term, which is closed and followed by
+// the end of the term. Otherwise it's a word, like /api.
+func isPatternAt(runes []rune, i int) bool {
+	end := closingDelimiter(runes, i)
+
+	return end >= 0 && (end+1 == len(runes) || unicode.IsSpace(runes[end+1]) || runes[end+1] == ')')
+}
+
+func operatorAt(runes []rune, i int) string {
+	rest := string(runes[i:])
+
+	for _, op := range queryOperators {
+		if strings.HasPrefix(rest, op) {
+			return op
+		}
+	}
+
+	return ""
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+
+	return &p.tokens[p.pos]
+}
+
+func (p *queryParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t == nil {
+		return false
+	}
+
+	for _, w := range words {
+		if (t.kind == tokenWord || t.kind == tokenOperator) && strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("or", "||") {
+		p.pos++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t == nil || t.kind == tokenClose || p.isKeyword("or", "||") {
+			return left, nil
+		}
+
+		if p.isKeyword("and", "&&") {
+			p.pos++
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.isKeyword("not", "!") {
+		p.pos++
+
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{inner}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	p.pos++
+
+	switch t.kind {
+	case tokenOpen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if close := p.peek(); close == nil || close.kind != tokenClose {
+			return nil, fmt.Errorf("missing )")
+		}
+
+		p.pos++
+
+		return inner, nil
+
+	case tokenRegex:
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern /%s/: %w", t.text, err)
+		}
+
+		return messageRegexNode{re}, nil
+
+	case tokenQuoted:
+		return messageNode{strings.ToLower(t.text)}, nil
+
+	case tokenWord:
+		if op := p.peek(); op != nil && op.kind == tokenOperator && isComparison(op.text) {
+			p.pos++
+			return p.parseComparison(t.text, op.text)
+		}
+
+		return messageNode{strings.ToLower(t.text)}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "!=", ">", ">=", "<", "<=", "~":
+		return true
+	}
+
+	return false
+}
+
+func (p *queryParser) parseComparison(field string, op string) (queryNode, error) {
+	t := p.peek()
+	if t == nil || (t.kind != tokenWord && t.kind != tokenQuoted && t.kind != tokenRegex) {
+		return nil, fmt.Errorf("missing value after %s%s", field, op)
+	}
+
+	p.pos++
+
+	node := compareNode{field: field, op: op, value: t.text}
+
+	if op == "~" || t.kind == tokenRegex {
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", field, err)
+		}
+
+		node.op = "~"
+		node.pattern = re
+	}
+
+	if n, err := strconv.ParseFloat(t.text, 64); err == nil && t.kind == tokenWord {
+		node.number = &n
+	} else if d, err := time.ParseDuration(t.text); err == nil && t.kind == tokenWord {
+		node.duration = &d
+	}
+
+	return node, nil
+}
+
+type andNode struct{ left, right queryNode }
+type orNode struct{ left, right queryNode }
+type notNode struct{ inner queryNode }
+type messageNode struct{ text string }
+type messageRegexNode struct{ pattern *regexp.Regexp }
+
+func (n andNode) match(line Line) bool { return n.left.match(line) && n.right.match(line) }
+func (n orNode) match(line Line) bool  { return n.left.match(line) || n.right.match(line) }
+func (n notNode) match(line Line) bool { return !n.inner.match(line) }
+
+func (n messageNode) match(line Line) bool {
+	return strings.Contains(strings.ToLower(line.Message), n.text)
+}
+
+func (n messageRegexNode) match(line Line) bool {
+	return n.pattern.MatchString(line.Message)
+}
+
+type compareNode struct {
+	field    string
+	op       string
+	value    string
+	pattern  *regexp.Regexp
+	number   *float64
+	duration *time.Duration
+}
+
+func (n compareNode) match(line Line) bool {
+	value, ok := lookupField(line, n.field)
+	if !ok {
+		return n.op == "!="
+	}
+
+	if n.pattern != nil {
+		return n.pattern.MatchString(fmt.Sprint(value))
+	}
+
+	if n.number != nil {
+		if f, ok := toFloat(value); ok {
+			return compareOrdered(f, *n.number, n.op)
+		}
+	}
+
+	if n.duration != nil {
+		if d, ok := toDuration(value); ok {
+			return compareOrdered(float64(d), float64(*n.duration), n.op)
+		}
+	}
+
+	str := strings.ToLower(fmt.Sprint(value))
+	want := strings.ToLower(n.value)
+
+	return compareOrdered(float64(strings.Compare(str, want)), 0, n.op)
+}
+
+func compareOrdered(a float64, b float64, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+
+	return false
+}
+
+// lookupField finds a field in the line's data, following dots into nested objects, then falls
+// back to the line's tags and its built in fields.
+func lookupField(line Line, field string) (interface{}, bool) {
+	// The line's level has been renamed by the config's levels, such as pino's 50 to error.
+	if field == "level" && line.Level != "" {
+		return line.Level, true
+	}
+
+	if value, ok := lookupData(line.Data, field); ok {
+		return value, true
+	}
+
+	for _, t := range line.Tags {
+		if t.Name == field {
+			return t.Value, true
+		}
+	}
+
+	switch field {
+	case "msg", "message":
+		return line.Message, true
+	case "level":
+		return line.Level, true
+	case "source":
+		return line.Source, line.Source != ""
+	case "time":
+		return line.Timestamp.Format(time.RFC3339Nano), !line.Timestamp.IsZero()
+	}
+
+	return nil, false
+}
+
+func lookupData(data map[string]interface{}, field string) (interface{}, bool) {
+	if value, ok := data[field]; ok {
+		return value, true
+	}
+
+	head, rest, found := strings.Cut(field, ".")
+	if !found {
+		return nil, false
+	}
+
+	nested, ok := data[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	return lookupData(nested, rest)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// toDuration reads a duration string, or a number of seconds.
+func toDuration(value interface{}) (time.Duration, bool) {
+	if f, ok := toFloat(value); ok {
+		return time.Duration(f * float64(time.Second)), true
+	}
+
+	if s, ok := value.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil
+	}
+
+	return 0, false
+}
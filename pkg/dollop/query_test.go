@@ -0,0 +1,187 @@
+package dollop
+
+import (
+	"strings"
+	"testing"
+)
+
+// describeTokens writes tokens compactly, like word:GET op:= regex:api.
+func describeTokens(tokens []queryToken) string {
+	kinds := map[tokenKind]string{
+		tokenWord:     "word",
+		tokenQuoted:   "quoted",
+		tokenRegex:    "regex",
+		tokenOperator: "op",
+		tokenOpen:     "open",
+		tokenClose:    "close",
+	}
+
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		parts[i] = kinds[t.kind] + ":" + t.text
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestTokenizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		err   string
+	}{
+		{query: "level=error", want: "word:level op:= word:error"},
+		{query: "status >= 500", want: "word:status op:>= word:500"},
+		{query: `msg="two words"`, want: `word:msg op:= quoted:two words`},
+		{query: `"say \"hi\""`, want: `quoted:say "hi"`},
+		{query: "(a || b) && !c", want: "open:( word:a op:|| word:b close:) op:&& op:! word:c"},
+		{query: "a!=b", want: "word:a op:!= word:b"},
+
+		// A slash is only a pattern as a term of its own, or after ~.
+		{query: "/time.*out/", want: "regex:time.*out"},
+		{query: "error /timeout/", want: "word:error regex:timeout"},
+		{query: "(/timeout/)", want: "open:( regex:timeout close:)"},
+		{query: `path~/^\/api/`, want: "word:path op:~ regex:^/api"},
+		{query: "path=/api/users", want: "word:path op:= word:/api/users"},
+		{query: "GET /api", want: "word:GET word:/api"},
+		{query: "GET /api/users", want: "word:GET word:/api/users"},
+		{query: "path~/api", err: "unterminated /"},
+
+		// ! only negates at the start of a term.
+		{query: "hello!", want: "word:hello!"},
+		{query: "!error", want: "op:! word:error"},
+		{query: "wow! such", want: "word:wow! word:such"},
+		{query: "msg=!important", want: "word:msg op:= word:!important"},
+
+		{query: `"open`, err: `unterminated "`},
+	}
+
+	for _, test := range tests {
+		tokens, err := tokenizeQuery(test.query)
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error = %v, want %s", test.query, err, test.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.query, err)
+			continue
+		}
+
+		if got := describeTokens(tokens); got != test.want {
+			t.Errorf("%s: tokens = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	lines := map[string]Line{
+		"users": {
+			Message: "users listed",
+			Level:   "info",
+			Data:    map[string]interface{}{"path": "/v2/api/orders", "status": float64(200), "duration": "250ms"},
+		},
+		"api": {
+			Message: "GET /api/users",
+			Level:   "error",
+			Data:    map[string]interface{}{"path": "/api/users", "status": float64(500), "duration": 1.5},
+			Tags:    []Tag{{Name: "slow"}},
+		},
+		"nested": {
+			Message: "hello!",
+			Level:   "warning",
+			Data: map[string]interface{}{
+				"http":   map[string]interface{}{"method": "POST"},
+				"status": "404",
+			},
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "users", want: "api users"},
+		{query: `"users listed"`, want: "users"},
+		{query: "level=error", want: "api"},
+		{query: "level!=error", want: "nested users"},
+		{query: "LEVEL=ERROR", want: ""},
+		{query: "level=ERROR", want: "api"},
+
+		// Numbers compare numerically, and as numbers when written as text.
+		{query: "status>=404", want: "api nested"},
+		{query: "status<300", want: "users"},
+		{query: "status=404", want: "nested"},
+
+		// Durations compare as durations, with plain numbers taken as seconds.
+		{query: "duration>1s", want: "api"},
+		{query: "duration<=250ms", want: "users"},
+
+		// Strings compare in order, ignoring case.
+		{query: "path>/api", want: "api users"},
+		{query: "http.method=post", want: "nested"},
+
+		{query: "path=/api/users", want: "api"},
+		{query: "path~^/api", want: "api"},
+		{query: `path~/^\/v2/`, want: "users"},
+		{query: "/^GET/", want: "api"},
+		{query: "GET /api", want: "api"},
+		{query: "hello!", want: "nested"},
+		{query: `slow=""`, want: "api"},
+
+		// not binds tightest, then and, then or.
+		{query: "level=info or level=error and status=200", want: "users"},
+		{query: "(level=info or level=error) and status=500", want: "api"},
+		{query: "not level=info and not level=error", want: "nested"},
+		{query: "!users || hello", want: "nested"},
+		{query: "level=error || level=warning && status=404", want: "api nested"},
+		{query: "users listed", want: "users"},
+		{query: "users and not listed", want: "api"},
+	}
+
+	for _, test := range tests {
+		q, err := ParseQuery(test.query)
+		if err != nil {
+			t.Errorf("%s: %s", test.query, err)
+			continue
+		}
+
+		matched := []string{}
+		for _, name := range []string{"api", "nested", "users"} {
+			if q.Match(lines[name]) {
+				matched = append(matched, name)
+			}
+		}
+
+		if got := strings.Join(matched, " "); got != test.want {
+			t.Errorf("%s: matched %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := map[string]string{
+		"":           "empty query",
+		"(a":         "missing )",
+		"a)":         `unexpected ")"`,
+		"not":        "unexpected end of query",
+		"level=":     "missing value after level=",
+		"level=)":    "missing value after level=",
+		"path~[":     "invalid pattern for path",
+		"/(/":        "invalid pattern /(/",
+		`"unclosed`:  `unterminated "`,
+		"msg~/open":  "unterminated /",
+		"a and || b": `unexpected "||"`,
+	}
+
+	for query, want := range tests {
+		_, err := ParseQuery(query)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: error = %v, want %s", query, err, want)
+		}
+	}
+}
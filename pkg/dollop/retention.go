@@ -0,0 +1,158 @@
+package dollop
+
+import "time"
+
+// Evict enforces the config's retention limits, removing the oldest groups until there are at most
+// MaxGroups, and at most MaxLines lines in total, and removing groups with no lines newer than
+// MaxAge. Groups which collect ungrouped lines, such as Text and No Group, have their oldest lines
+// trimmed instead, and aren't counted towards MaxGroups. Groups containing errors are kept when
+// PinErrors is set. Call after Reorder. Returns the evicted groups, and see Trimmed for the lines
+// removed from the others.
+func (p *Processor) Evict(now time.Time) []*Group {
+	c := p.config
+	if c.MaxGroups <= 0 && c.MaxLines <= 0 && c.MaxAge <= 0 {
+		return nil
+	}
+
+	evicted := map[*Group]struct{}{}
+
+	remainingGroups := 0
+	for _, g := range p.order {
+		if !g.catchAll {
+			remainingGroups++
+		}
+	}
+
+	// Walk from the oldest group, which is at the end of the order.
+	for i := len(p.order) - 1; i >= 0; i-- {
+		g := p.order[i]
+
+		tooMany := c.MaxGroups > 0 && remainingGroups > c.MaxGroups
+		tooLong := c.MaxLines > 0 && p.totalLines > c.MaxLines
+		tooOld := c.MaxAge > 0 && g.Timestamp.Before(now.Add(-c.MaxAge))
+
+		if !tooMany && !tooLong && !tooOld {
+			// Groups are newest first, but catch-all groups can be ordered by a stale timestamp,
+			// so only stop once no limit could apply to anything before this group.
+			if !g.catchAll {
+				break
+			}
+
+			continue
+		}
+
+		if g.catchAll {
+			p.trim(g, now, tooLong, tooOld)
+			continue
+		}
+
+		if c.PinErrors && g.HasErrors() {
+			continue
+		}
+
+		evicted[g] = struct{}{}
+		remainingGroups--
+		p.totalLines -= len(g.Lines)
+	}
+
+	if len(evicted) == 0 {
+		return nil
+	}
+
+	result := make([]*Group, 0, len(evicted))
+	order := make([]*Group, 0, len(p.order)-len(evicted))
+
+	for _, g := range p.order {
+		if _, ok := evicted[g]; ok {
+			result = append(result, g)
+			p.forget(g)
+		} else {
+			order = append(order, g)
+		}
+	}
+
+	p.order = order
+	p.evicted += len(result)
+
+	return result
+}
+
+// Trimmed returns how many lines have been removed from the start of each group by the retention
+// limits since the last call, so anything holding the positions of lines can move them to match.
+func (p *Processor) Trimmed() map[*Group]int {
+	result := p.trimmed
+	p.trimmed = map[*Group]int{}
+
+	return result
+}
+
+// EvictedCount returns the number of groups evicted so far.
+func (p *Processor) EvictedCount() int {
+	return p.evicted
+}
+
+// trim removes the oldest lines from a catch-all group, either enough to bring the total under
+// MaxLines, or those older than MaxAge.
+func (p *Processor) trim(g *Group, now time.Time, tooLong bool, tooOld bool) {
+	n := 0
+
+	if tooLong {
+		n = p.totalLines - p.config.MaxLines
+	}
+
+	if tooOld {
+		cutoff := now.Add(-p.config.MaxAge)
+		for n < len(g.Lines) && g.Lines[n].Timestamp.Before(cutoff) {
+			n++
+		}
+	}
+
+	if n > len(g.Lines) {
+		n = len(g.Lines)
+	}
+
+	if n == 0 {
+		return
+	}
+
+	// Clear the trimmed lines so their data can be collected before the slice is next reallocated.
+	for i := 0; i < n; i++ {
+		if isErrorLevel(g.Lines[i].Level) {
+			g.errors--
+		}
+
+		g.Lines[i] = Line{}
+	}
+
+	g.Lines = g.Lines[n:]
+	p.totalLines -= n
+	p.changed[g] = struct{}{}
+	p.trimmed[g] += n
+
+	for source, target := range p.continuationTargets {
+		if target.group != g {
+			continue
+		}
+
+		if target.index < n {
+			delete(p.continuationTargets, source)
+		} else {
+			target.index -= n
+			p.continuationTargets[source] = target
+		}
+	}
+}
+
+// forget removes all references to an evicted group.
+func (p *Processor) forget(g *Group) {
+	delete(p.groups, g.Value)
+	delete(p.unsorted, g)
+	delete(p.changed, g)
+	delete(p.trimmed, g)
+
+	for source, target := range p.continuationTargets {
+		if target.group == g {
+			delete(p.continuationTargets, source)
+		}
+	}
+}
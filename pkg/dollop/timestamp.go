@@ -0,0 +1,121 @@
+package dollop
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// timestampLayouts are tried in turn when the timestamp format is auto.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999",
+	"02/Jan/2006:15:04:05 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// parseTime reads a timestamp in the config's format. Times without a zone are read in the config's
+// timezone, or the local one.
+func parseTime(config Config, text string) (time.Time, bool) {
+	loc := config.location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if config.timestampLayout != "" {
+		t, err := time.ParseInLocation(config.timestampLayout, text, loc)
+		return t, err == nil
+	}
+
+	switch config.TimestampFormat {
+	case "rfc3339", "rfc3339nano":
+		t, err := time.Parse(time.RFC3339Nano, text)
+		return t, err == nil
+	case "unix":
+		return parseEpoch(text, time.Second)
+	case "unix_ms":
+		return parseEpoch(text, time.Millisecond)
+	case "unix_ns":
+		return parseEpoch(text, time.Nanosecond)
+	}
+
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return parseEpoch(text, epochUnit(text, n))
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// epochThresholds are the sizes below which an epoch is taken to be in each unit.
+var epochThresholds = []struct {
+	below int64
+	unit  time.Duration
+}{
+	{below: 1e11, unit: time.Second},
+	{below: 1e14, unit: time.Millisecond},
+	{below: 1e17, unit: time.Microsecond},
+}
+
+// epochUnit guesses whether an epoch is in seconds, milliseconds, microseconds or nanoseconds by its
+// size, which works for any time after 1973. Integers are compared exactly, as those near the
+// thresholds are beyond a float64's precision.
+func epochUnit(text string, f float64) time.Duration {
+	n, err := strconv.ParseInt(text, 10, 64)
+
+	for _, t := range epochThresholds {
+		if err == nil && n > -t.below && n < t.below || err != nil && math.Abs(f) < float64(t.below) {
+			return t.unit
+		}
+	}
+
+	return time.Nanosecond
+}
+
+// parseEpoch reads a number of units since the epoch. JSON numbers are often written with exponents,
+// such as 1.697e+09, so those are read as floats. The whole seconds are kept apart from the rest, so
+// times beyond 2262 don't overflow.
+func parseEpoch(text string, unit time.Duration) (time.Time, bool) {
+	perSecond := int64(time.Second / unit)
+
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Unix(n/perSecond, n%perSecond*int64(unit)), true
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	whole, fraction := math.Modf(f)
+	n := int64(whole)
+
+	return time.Unix(n/perSecond, n%perSecond*int64(unit)+int64(fraction*float64(unit))), true
+}
+
+// inZone shows a time in the config's timezone, if it has one.
+func inZone(config Config, t time.Time) time.Time {
+	if config.location == nil || t.IsZero() {
+		return t
+	}
+
+	return t.In(config.location)
+}
@@ -0,0 +1,97 @@
+package dollop
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	at := time.Date(2023, 10, 15, 12, 30, 45, 0, time.UTC)
+	frac := at.Add(123456789 * time.Nanosecond)
+
+	tests := []struct {
+		format string
+		text   string
+		want   time.Time
+	}{
+		// Epochs are told apart by their size.
+		{format: "auto", text: "1697373045", want: at},
+		{format: "auto", text: "1697373045.5", want: at.Add(500 * time.Millisecond)},
+		{format: "auto", text: "1697373045123", want: at.Add(123 * time.Millisecond)},
+		{format: "auto", text: "1697373045123456", want: frac.Truncate(time.Microsecond)},
+		{format: "auto", text: "1697373045123456789", want: frac},
+		{format: "auto", text: "1.697373045e+09", want: at},
+
+		// Just under and at each threshold.
+		{format: "auto", text: "99999999999", want: time.Unix(99999999999, 0)},
+		{format: "auto", text: "100000000000", want: time.UnixMilli(100000000000)},
+		{format: "auto", text: "99999999999999", want: time.UnixMilli(99999999999999)},
+		{format: "auto", text: "100000000000000", want: time.UnixMicro(100000000000000)},
+		{format: "auto", text: "99999999999999999", want: time.UnixMicro(99999999999999999)},
+		{format: "auto", text: "100000000000000000", want: time.Unix(0, 100000000000000000)},
+		{format: "auto", text: "0", want: time.Unix(0, 0)},
+		{format: "auto", text: "-1697373045", want: time.Unix(-1697373045, 0)},
+		{format: "auto", text: "1.697373045123e+12", want: at.Add(123 * time.Millisecond)},
+
+		// Named formats don't guess the unit.
+		{format: "unix", text: "1697373045123", want: time.Unix(1697373045123, 0)},
+		{format: "unix_ms", text: "1697373045", want: time.UnixMilli(1697373045)},
+		{format: "unix_ns", text: "1697373045", want: time.Unix(0, 1697373045)},
+		{format: "rfc3339", text: "2023-10-15T12:30:45Z", want: at},
+
+		{format: "auto", text: "2023-10-15T12:30:45.123456789Z", want: frac},
+		{format: "auto", text: "2023-10-15T14:30:45+02:00", want: at},
+		{format: "auto", text: "2023-10-15 12:30:45,123", want: at.Add(123 * time.Millisecond)},
+		{format: "auto", text: "15/Oct/2023:12:30:45 +0000", want: at},
+
+		// Layouts, in Go's form or strftime's. Times without a zone are read in the config's.
+		{format: "02/01/2006 15:04", text: "15/10/2023 12:30", want: at.Truncate(time.Minute)},
+		{format: "%Y-%m-%d %H:%M:%S", text: "2023-10-15 12:30:45", want: at},
+		{format: "%d %b %Y %I:%M:%S %p", text: "15 Oct 2023 12:30:45 PM", want: at},
+	}
+
+	for _, test := range tests {
+		config := Config{TimestampFormat: test.format, Timezone: "UTC"}
+		if err := config.prepareFormats(); err != nil {
+			t.Fatalf("%s: %s", test.format, err)
+		}
+
+		got, ok := parseTime(config, test.text)
+		if !ok || !got.Equal(test.want) {
+			t.Errorf("%s %q = %s %v, want %s", test.format, test.text, got, ok, test.want)
+		}
+	}
+}
+
+func TestParseTimeFailures(t *testing.T) {
+	tests := []struct{ format, text string }{
+		{format: "auto", text: "yesterday"},
+		{format: "auto", text: ""},
+		{format: "unix", text: "soon"},
+		{format: "rfc3339", text: "2023-10-15 12:30:45"},
+		{format: "%Y-%m-%d", text: "15/10/2023"},
+	}
+
+	for _, test := range tests {
+		config := Config{TimestampFormat: test.format}
+		if err := config.prepareFormats(); err != nil {
+			t.Fatalf("%s: %s", test.format, err)
+		}
+
+		if got, ok := parseTime(config, test.text); ok {
+			t.Errorf("%s %q = %s, want it not to parse", test.format, test.text, got)
+		}
+	}
+}
+
+func TestParseTimeInTimezone(t *testing.T) {
+	config := Config{TimestampFormat: "%Y-%m-%d %H:%M", Timezone: "America/New_York"}
+	if err := config.prepareFormats(); err != nil {
+		t.Skipf("timezone data isn't available: %s", err)
+	}
+
+	got, ok := parseTime(config, "2023-10-15 08:30")
+	if want := time.Date(2023, 10, 15, 12, 30, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("time without a zone = %s, want it read in New York, %s", got, want)
+	}
+}